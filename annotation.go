@@ -0,0 +1,60 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// matches the delimiter of user text after a reference of the child issue line, the text
+// is separated with " — " or " -- " and it is kept as is on updates
+var annotationRegexp = regexp.MustCompile(
+	`(?:#\d{1,10}|/(?:issues|pull)/\d{1,10}\)?)(?:~~)?(?:` + lineRollupPattern + `)?( (?:—|--) )`)
+
+// splitScore tells how likely the managed part ends with the reference rendered by the editor
+func splitScore(managed string, ctx *editContext) int {
+	id, err := findIssueRef(managed, ctx.Owner, ctx.Repo, ctx.RefFirst)
+	if err != nil {
+		return 0
+	}
+
+	ci, ok := ctx.Issues[id]
+	switch {
+	case !ok:
+		return 0
+	case strings.Contains(managed, ci.Title):
+		return 2
+	default:
+		return 1
+	}
+}
+
+// splitAnnotation returns managed part of the line and the annotation with the delimiter,
+// titles can contain references and delimiters too, so the line is split after
+// the reference of a known child issue and at the first delimiter otherwise
+func splitAnnotation(line string, ctx *editContext) (string, string) {
+	matches := annotationRegexp.FindAllStringSubmatchIndex(line, -1)
+	if len(matches) == 0 {
+		return line, ""
+	}
+
+	best, bestScore := matches[0][2], -1
+	for _, m := range matches {
+		if score := splitScore(line[:m[2]], ctx); score > bestScore {
+			best, bestScore = m[2], score
+		}
+	}
+
+	// the whole line has no annotation
+	if splitScore(line, ctx) > bestScore {
+		return line, ""
+	}
+
+	return line[:best], line[best:]
+}
+
+// lineRef parses the reference of the child issue line ignoring the annotation
+func lineRef(line string, ctx *editContext) (IssueRef, error) {
+	managed, _ := splitAnnotation(line, ctx)
+
+	return findIssueRef(managed, ctx.Owner, ctx.Repo, ctx.RefFirst)
+}
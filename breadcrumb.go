@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	breadcrumbStartMarker = "<!-- parent-issue-update:breadcrumb:start -->"
+	breadcrumbEndMarker   = "<!-- parent-issue-update:breadcrumb:end -->"
+	breadcrumbSeparator   = " › "
+	breadcrumbSelf        = "this issue"
+)
+
+func hasBreadcrumb(body string) bool {
+	return strings.Contains(body, breadcrumbStartMarker)
+}
+
+// formatBreadcrumb renders the chain of ancestors and progress of the direct parent
+func (e *Editor) formatBreadcrumb(i *Issue, ancestors []*Issue, lineEOL string) string {
+	parts := make([]string, 0, len(ancestors)+1)
+	for _, a := range ancestors {
+		parts = append(parts, fmt.Sprintf("%s %s", a.Title, a.Ref().Relative(i.Owner, i.Repo)))
+	}
+	parts = append(parts, breadcrumbSelf)
+
+	parent := ancestors[len(ancestors)-1]
+	progress := strings.TrimPrefix(e.formatProgress(parent), progressPrefix+" ")
+
+	return breadcrumbStartMarker + lineEOL +
+		strings.Join(parts, breadcrumbSeparator) + lineEOL +
+		"Parent progress: " + progress + lineEOL +
+		breadcrumbEndMarker
+}
+
+// UpdateBreadcrumb puts the breadcrumb to the top of the body of the child issue,
+// the breadcrumb is removed from issues that are not children anymore
+func (e *Editor) UpdateBreadcrumb(i *Issue, body string) string {
+	if e.Ancestors == nil {
+		return body
+	}
+
+	ancestors := e.Ancestors(i)
+	lineEOL := detectEOL(body)
+
+	start := strings.Index(body, breadcrumbStartMarker)
+	end := -1
+	if start != -1 {
+		if n := strings.Index(body[start:], breadcrumbEndMarker); n != -1 {
+			end = start + n + len(breadcrumbEndMarker)
+		}
+	}
+
+	if len(ancestors) == 0 {
+		if end == -1 {
+			return body
+		}
+
+		rest := strings.TrimLeft(body[end:], "\r\n")
+		return body[:start] + rest
+	}
+
+	crumb := e.formatBreadcrumb(i, ancestors, lineEOL)
+
+	if end == -1 {
+		if len(body) == 0 {
+			return crumb
+		}
+		return crumb + lineEOL + lineEOL + body
+	}
+
+	return body[:start] + crumb + body[end:]
+}
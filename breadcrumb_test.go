@@ -0,0 +1,71 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestUpdateBreadcrumb(t *testing.T) {
+	epic := &Issue{ID: 1, Title: "Epic", Owner: "owner", Repo: "planning"}
+	story := &Issue{ID: 12, Title: "Story", Owner: "owner", Repo: "repo"}
+	child := &Issue{ID: 20, Owner: "owner", Repo: "repo"}
+	story.Children = []*Issue{child, &Issue{ID: 21, Owner: "owner", Repo: "repo", Status: StatusClosed}}
+	epic.Children = []*Issue{story}
+
+	crumb := "<!-- parent-issue-update:breadcrumb:start -->\n" +
+		"Epic owner/planning#1 › Story #12 › this issue\n" +
+		"Parent progress: 1/2 (50%)\n" +
+		"<!-- parent-issue-update:breadcrumb:end -->"
+	oldCrumb := "<!-- parent-issue-update:breadcrumb:start -->\nStory #12 › this issue\n<!-- parent-issue-update:breadcrumb:end -->"
+
+	tests := []struct {
+		body      string
+		ancestors []*Issue
+		expected  string
+	}{
+		{"", []*Issue{epic, story}, crumb},
+		{"Parent: #12", []*Issue{epic, story}, crumb + "\n\nParent: #12"},
+		{crumb + "\n\nParent: #12", []*Issue{epic, story}, crumb + "\n\nParent: #12"},
+		{oldCrumb + "\n\nParent: #12", []*Issue{epic, story}, crumb + "\n\nParent: #12"},
+		{crumb + "\n\nText", nil, "Text"},
+		{"Text", nil, "Text"},
+	}
+
+	for _, tt := range tests {
+		e := &Editor{Ancestors: func(i *Issue) []*Issue { return tt.ancestors }}
+		body := e.UpdateBreadcrumb(child, tt.body)
+		if body != tt.expected {
+			t.Errorf("Body does not match. actual=%v expected=%v", body, tt.expected)
+		}
+	}
+}
+
+func TestUpdateBreadcrumbCRLF(t *testing.T) {
+	parent := &Issue{ID: 12, Title: "Story", Children: []*Issue{&Issue{ID: 20}}}
+	e := &Editor{Ancestors: func(i *Issue) []*Issue { return []*Issue{parent} }}
+
+	body := e.UpdateBreadcrumb(&Issue{ID: 20}, "Parent: #12\r\nText")
+	expected := "<!-- parent-issue-update:breadcrumb:start -->\r\nStory #12 › this issue\r\nParent progress: 0/1 (0%)\r\n" +
+		"<!-- parent-issue-update:breadcrumb:end -->\r\n\r\nParent: #12\r\nText"
+	if body != expected {
+		t.Errorf("Body does not match. actual=%q expected=%q", body, expected)
+	}
+}
+
+func TestUpdateBreadcrumbUnfetched(t *testing.T) {
+	child := &Issue{ID: 20, Status: StatusClosed}
+	// only the child updated in this run is fetched
+	parent := &Issue{ID: 12, Title: "Story", Children: []*Issue{child}}
+	parent.Listed = map[IssueRef]bool{
+		NewIssueRef("", "", 20): false,
+		NewIssueRef("", "", 21): true,
+		NewIssueRef("", "", 22): false,
+	}
+	e := &Editor{Ancestors: func(i *Issue) []*Issue { return []*Issue{parent} }}
+
+	body := e.UpdateBreadcrumb(child, "Parent: #12")
+	expected := "<!-- parent-issue-update:breadcrumb:start -->\nStory #12 › this issue\nParent progress: 2/3 (66%)\n" +
+		"<!-- parent-issue-update:breadcrumb:end -->\n\nParent: #12"
+	if body != expected {
+		t.Errorf("Body does not match. actual=%q expected=%q", body, expected)
+	}
+}
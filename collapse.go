@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+)
+
+const detailsClose = "</details>"
+
+// matches lines of details blocks generated by the editor
+var detailsRegexp = regexp.MustCompile(`^\s*(<details><summary>.*</summary>|</details>)\s*$`)
+
+func isDetailsLine(line string) bool {
+	return detailsRegexp.MatchString(line)
+}
+
+func detailsOpen(spaces int, summary string) string {
+	return fmt.Sprintf("%s<details><summary>%s</summary>", strings.Repeat(" ", spaces), summary)
+}
+
+func (e *Editor) collapsing() bool {
+	return e.Render == RenderChecklist && (e.CollapseNested || e.CollapseAfter > 0)
+}
+
+func (e *Editor) formatSummary(i *Issue, ctx *editContext) string {
+	return html.EscapeString(fmt.Sprintf("%s %s (%v)", i.Title, i.Ref().Relative(ctx.Owner, ctx.Repo), e.progressOf(i)))
+}
+
+// collapse wraps children of sub-parents and long lists of children into details blocks,
+// it expects lines without details blocks
+func (e *Editor) collapse(section string, issueMap map[IssueRef]*Issue, ctx *editContext) string {
+	if !e.collapsing() {
+		return section
+	}
+
+	lines := e.collapseLines(strings.Split(section, eol), issueMap, ctx)
+
+	// closing tag is followed by a blank line, do not add one more
+	result := make([]string, 0, len(lines))
+	for n, l := range lines {
+		if n >= 2 && isAllWhitespace(l) && isAllWhitespace(lines[n-1]) && isDetailsLine(lines[n-2]) {
+			continue
+		}
+		result = append(result, l)
+	}
+
+	return strings.Join(result, eol)
+}
+
+func (e *Editor) collapseLines(lines []string, issueMap map[IssueRef]*Issue, ctx *editContext) []string {
+	result := make([]string, 0, len(lines))
+	run := make([]*lineBlock, 0)
+	indent := -1
+
+	flush := func() {
+		more := e.CollapseAfter > 0 && len(run) > e.CollapseAfter
+
+		for n, b := range run {
+			if more && n == e.CollapseAfter {
+				summary := fmt.Sprintf("Show %v more", len(run)-n)
+				result = append(result, detailsOpen(indent, summary), "")
+			}
+
+			result = append(result, b.lines[0])
+			nested := e.collapseLines(b.lines[1:], issueMap, ctx)
+			if e.CollapseNested && b.issue != nil && len(nested) > 0 {
+				spaces := countPrefixSpaces(b.lines[1])
+				result = append(result, detailsOpen(spaces, e.formatSummary(b.issue, ctx)), "")
+				result = append(result, nested...)
+				result = append(result, "", strings.Repeat(" ", spaces)+detailsClose, "")
+			} else {
+				result = append(result, nested...)
+			}
+		}
+
+		if more {
+			result = append(result, "", strings.Repeat(" ", indent)+detailsClose, "")
+		}
+		run = run[:0]
+	}
+
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		spaces := countPrefixSpaces(line)
+		if indent == -1 && !isAllWhitespace(line) {
+			indent = spaces
+		}
+
+		id, err := lineRef(line, ctx)
+		if isAllWhitespace(line) || spaces != indent || err != nil || isGroupHeading(line) {
+			flush()
+			result = append(result, line)
+			i++
+			continue
+		}
+
+		block := &lineBlock{issue: issueMap[id], lines: []string{line}}
+		for i++; i < len(lines) && !isAllWhitespace(lines[i]) && countPrefixSpaces(lines[i]) > indent; i++ {
+			block.lines = append(block.lines, lines[i])
+		}
+		run = append(run, block)
+	}
+
+	flush()
+
+	return result
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"log"
+	"strings"
+
+	"github.com/google/go-github/v73/github"
+)
+
+// hidden marker of the comment with the child section
+const childrenCommentMarker = "<!-- parent-issue-update:children -->"
+
+func isChildrenComment(body string) bool {
+	return strings.HasPrefix(strings.TrimLeft(body, " \r\n\t"), childrenCommentMarker)
+}
+
+// childrenCommentSection returns the text of the comment after the marker
+func childrenCommentSection(body string) string {
+	body = strings.TrimPrefix(strings.TrimLeft(body, " \r\n\t"), childrenCommentMarker)
+
+	return strings.TrimLeft(body, "\r\n")
+}
+
+func (s *service) findChildrenComment(ref IssueRef) (*github.IssueComment, error) {
+	return s.findComment(ref, isChildrenComment)
+}
+
+// updateComment updates the child section in the comment and the status label of the parent issue
+func (s *service) updateComment(e *Editor, i *Issue, labels *LabelChange) {
+	comment, err := s.findChildrenComment(i.Ref())
+	if err != nil {
+		log.Printf("Error while listing comments. issue=%v err=%v", i.Ref(), err)
+		return
+	}
+
+	// the comment is edited the same way as the issue body
+	parent := *i
+	parent.Body = childrenCommentSection(comment.GetBody())
+	body, changeLog, err := e.Update(&parent, true /*add missing*/)
+	if err != nil {
+		log.Printf("Failed to update children comment. issue=%v err=%v", i.Ref(), err)
+		return
+	}
+
+	changed := body != parent.Body
+	changeLog = s.changeLabels(i, labels, changed, changeLog)
+
+	if !changed {
+		log.Printf("Skipping identical children comment. issue=%v", i.Ref())
+		return
+	}
+
+	s.wg.Add(1)
+	go s.updateChildrenComment(i, comment, body, changeLog)
+}
+
+// updateChildrenComment creates or edits the comment with the child section
+func (s *service) updateChildrenComment(i *Issue, existing *github.IssueComment, section string, changelog []string) {
+	defer s.wg.Done()
+
+	ref := i.Ref()
+	log.Printf("About to update children comment. issue=%v", ref)
+	if s.env.dryRun {
+		log.Printf("Dry run mode.")
+		return
+	}
+
+	body := childrenCommentMarker + eol + section
+	comment := &github.IssueComment{
+		Body: &body,
+	}
+
+	var err error
+	if existing == nil {
+		_, _, err = s.client.Issues.CreateComment(s.ctx, ref.Owner, ref.Repo, ref.Number, comment)
+	} else {
+		_, _, err = s.client.Issues.EditComment(s.ctx, ref.Owner, ref.Repo, existing.GetID(), comment)
+	}
+
+	if err != nil {
+		log.Printf("Error while updating children comment. issue=%v err=%v", ref, err)
+		return
+	}
+
+	log.Printf("Updated children comment. issue=%v", ref)
+
+	s.addChangelog(ref, changelog)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-github/v73/github"
+)
+
+func TestFindChildrenComment(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /repos/owner/repo/issues/1/comments", func(w http.ResponseWriter, r *http.Request) {
+		comments := []*github.IssueComment{
+			&github.IssueComment{ID: github.Ptr(int64(10)), Body: github.Ptr("Human comment")},
+			&github.IssueComment{ID: github.Ptr(int64(11)), Body: github.Ptr(childrenCommentMarker + "\n### Child issues:\n")},
+		}
+		json.NewEncoder(w).Encode(comments)
+	})
+
+	svc := newTestService(t, mux)
+	comment, err := svc.findChildrenComment(IssueRef{Owner: "owner", Repo: "repo", Number: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if comment.GetID() != 11 {
+		t.Errorf("Wrong comment is found. actual=%v expected=%v", comment.GetID(), 11)
+	}
+
+	if section := childrenCommentSection(comment.GetBody()); section != "### Child issues:\n" {
+		t.Errorf("Section does not match. actual=%q", section)
+	}
+}
+
+func TestUpdateChildrenComment(t *testing.T) {
+	created, edited := "", ""
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /repos/owner/repo/issues/1/comments", func(w http.ResponseWriter, r *http.Request) {
+		var c github.IssueComment
+		json.NewDecoder(r.Body).Decode(&c)
+		created = c.GetBody()
+		fmt.Fprint(w, `{"id":20}`)
+	})
+	mux.HandleFunc("PATCH /repos/owner/repo/issues/comments/11", func(w http.ResponseWriter, r *http.Request) {
+		var c github.IssueComment
+		json.NewDecoder(r.Body).Decode(&c)
+		edited = c.GetBody()
+		fmt.Fprint(w, `{"id":11}`)
+	})
+	mux.HandleFunc("PATCH /repos/owner/repo/issues/1", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Issue body was changed")
+	})
+
+	svc := newTestService(t, mux)
+	i := &Issue{ID: 1, Owner: "owner", Repo: "repo", Body: "Description"}
+
+	svc.wg.Add(1)
+	svc.updateChildrenComment(i, nil, "### Child issues:\n", nil)
+	if created != childrenCommentMarker+"\n### Child issues:\n" {
+		t.Errorf("Created comment does not match. actual=%q", created)
+	}
+
+	svc.wg.Add(1)
+	svc.updateChildrenComment(i, &github.IssueComment{ID: github.Ptr(int64(11))}, "### Child issues:\n- [ ] a #2\n", nil)
+	if edited != childrenCommentMarker+"\n### Child issues:\n- [ ] a #2\n" {
+		t.Errorf("Edited comment does not match. actual=%q", edited)
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	codeFence    = "```"
+	mermaidFence = "```mermaid"
+)
+
+var nodeIDRegexp = regexp.MustCompile(`[^a-zA-Z0-9]+`)
+
+func isCodeFence(line string) bool {
+	return strings.HasPrefix(strings.TrimSpace(line), codeFence)
+}
+
+func isMermaidFence(line string) bool {
+	return strings.TrimSpace(line) == mermaidFence
+}
+
+func diagramNodeID(r IssueRef, owner, repo string) string {
+	if r.SameRepo(owner, repo) {
+		return fmt.Sprintf("i%v", r.Number)
+	}
+
+	return "i_" + nodeIDRegexp.ReplaceAllString(r.String(), "_")
+}
+
+func diagramLabel(i *Issue, owner, repo string) string {
+	label := i.Ref().Relative(owner, repo) + " " + i.Title
+
+	return strings.ReplaceAll(label, `"`, "#quot;")
+}
+
+// formatDiagram renders the whole hierarchy of the issue as a mermaid flowchart
+func (e *Editor) formatDiagram(root *Issue) string {
+	var str strings.Builder
+
+	owner, repo := root.Owner, root.Repo
+	visited := make(map[IssueRef]bool)
+	nodes := make([]*Issue, 0)
+	edges := make([]string, 0)
+
+	var walk func(i *Issue)
+	walk = func(i *Issue) {
+		if visited[i.Ref()] {
+			return
+		}
+		visited[i.Ref()] = true
+		nodes = append(nodes, i)
+
+		for _, ci := range e.sorted(i.Children) {
+			edges = append(edges, fmt.Sprintf("  %s --> %s",
+				diagramNodeID(i.Ref(), owner, repo), diagramNodeID(ci.Ref(), owner, repo)))
+			walk(ci)
+		}
+	}
+	walk(root)
+
+	str.WriteString(mermaidFence + eol)
+	str.WriteString("flowchart TD" + eol)
+
+	for _, i := range nodes {
+		str.WriteString(fmt.Sprintf("  %s[\"%s\"]", diagramNodeID(i.Ref(), owner, repo), diagramLabel(i, owner, repo)) + eol)
+	}
+
+	for _, edge := range edges {
+		str.WriteString(edge + eol)
+	}
+
+	for _, i := range nodes {
+		id := diagramNodeID(i.Ref(), owner, repo)
+		class := "opened"
+		if i.IsDone(e.OnlyMergedDone) {
+			class = "done"
+		}
+
+		str.WriteString(fmt.Sprintf("  click %s href \"%s\"", id, i.URL()) + eol)
+		str.WriteString(fmt.Sprintf("  class %s %s", id, class) + eol)
+	}
+
+	str.WriteString("  classDef opened fill:#ddf4ff,stroke:#0969da" + eol)
+	str.WriteString("  classDef done fill:#dafbe1,stroke:#1a7f37" + eol)
+	str.WriteString(codeFence + eol)
+
+	return str.String()
+}
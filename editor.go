@@ -6,8 +6,9 @@ import (
 	"fmt"
 	"io"
 	"log"
-	"strconv"
+	"regexp"
 	"strings"
+	"text/template"
 	"unicode"
 )
 
@@ -23,6 +24,24 @@ var (
 	errLevelTooDeep = errors.New("level is too deep")
 )
 
+type NotPlannedStyle int
+
+const (
+	NotPlannedStrike NotPlannedStyle = iota
+	NotPlannedCheck
+)
+
+type PruneMode int
+
+const (
+	PruneNone PruneMode = iota
+	PruneRemove
+	PruneStrike
+)
+
+// matches list item prefix with optional checkbox
+var listItemRegexp = regexp.MustCompile(`^(\s*[-*+]\s+(?:\[[ xX]\]\s+)?)(.*)$`)
+
 type stack struct {
 	data []*Issue
 }
@@ -54,8 +73,20 @@ func (s *stack) pop() {
 type editContext struct {
 	ChangeLog  []string
 	AddMissing bool
-	Processed  map[int]bool
-	Stack      *stack
+	// links already present in the issue body
+	Processed map[issueLink]bool
+	// issues with already logged updates
+	Logged map[IssueRef]bool
+	Stack  *stack
+	// repository of the issue being edited
+	Owner string
+	Repo  string
+	// issue reference goes before the title in the line template
+	RefFirst bool
+	// style of the existing section
+	Format sectionFormat
+	// all descendants of the issue being edited
+	Issues map[IssueRef]*Issue
 }
 
 func isKnownError(err error) bool {
@@ -65,19 +96,150 @@ func isKnownError(err error) bool {
 
 type Editor struct {
 	MaxLevels int
+	Prune     PruneMode
+	// only merged pull requests are checked as done
+	OnlyMergedDone bool
+	NotPlanned     NotPlannedStyle
+	// maintain progress summary and rolled-up counts of sub-parents
+	ShowProgress bool
+	ProgressBar  bool
+	// percentage of progress is weighted by estimates of the children
+	WeightedProgress bool
+	// children of sub-parents are put into details blocks
+	CollapseNested bool
+	// children after this count are put into "show more" block
+	CollapseAfter int
+	// maintain total and remaining estimate of the children
+	ShowEstimates bool
+	// maintain mermaid diagram of the whole hierarchy
+	ShowDiagram    bool
+	SortBy         SortPolicy
+	PriorityLabels []string
+	// sort lines of the existing section too
+	Resort bool
+	Render RenderMode
+	// keep the section between hidden markers
+	Markers bool
+	// heading of the section, default is used if not set
+	Heading  string
+	Position SectionPosition
+	// top-level children are grouped under sub-headings
+	GroupBy          GroupPolicy
+	GroupLabelPrefix string
+	// renders child issue line without indentation,
+	// default template is used if not set
+	LineTemplate *template.Template
+	// Linked verifies that child still references the parent,
+	// it is used for children that are not known to the editor
+	Linked func(parent, child IssueRef) bool
+	// Ancestors returns parents of the issue from the root to the direct parent,
+	// breadcrumbs are maintained in child issues if set
+	Ancestors func(i *Issue) []*Issue
+}
+
+func (e *Editor) isLinked(parent *Issue, child IssueRef) bool {
+	for _, ci := range parent.Children {
+		if ci.Ref() == child {
+			return true
+		}
+	}
+
+	if e.Linked == nil {
+		return true
+	}
+
+	return e.Linked(parent.Ref(), child)
+}
+
+// isItemLine checks if the line is a list item or a table row that can be a child issue
+func isItemLine(line string) bool {
+	return listItemRegexp.MatchString(line) || isTableRow(line)
+}
+
+func strikeLine(line string) (string, bool) {
+	m := listItemRegexp.FindStringSubmatch(line)
+	if m == nil {
+		return line, false
+	}
+
+	// strike through the whole line even if a part of it was struck before
+	text := strings.TrimSpace(m[2])
+	struck := "~~" + strings.ReplaceAll(text, "~~", "") + "~~"
+	if text == struck {
+		return line, false
+	}
+
+	return m[1] + struck, true
+}
+
+// pruneLine removes or strikes through the child that does not reference the parent anymore
+func (e *Editor) pruneLine(line string, id IssueRef, str io.StringWriter, ctx *editContext) {
+	ref := id.Relative(ctx.Owner, ctx.Repo)
+
+	if e.Prune == PruneRemove {
+		log.Printf("Removing stale child issue. id=%v", id)
+		ctx.log(fmt.Sprintf("Removed child issue %v", ref))
+		return
+	}
+
+	managed, annotation := splitAnnotation(line, ctx)
+	struck, changed := e.renderer().strike(managed)
+	if changed {
+		log.Printf("Striking through stale child issue. id=%v", id)
+		ctx.log(fmt.Sprintf("Struck out child issue %v", ref))
+	}
+	str.WriteString(struck + annotation + eol)
+}
+
+// formatTitle renders the issue as a list item as seen from the edited issue
+func (e *Editor) formatTitle(i *Issue, spaces int, ctx *editContext) string {
+	done := i.IsDone(e.OnlyMergedDone)
+
+	data := &lineData{
+		Checkbox:  "[ ]",
+		Done:      done,
+		Status:    i.Status.String(),
+		Emoji:     statusEmoji(i, done),
+		Marker:    i.marker(),
+		Title:     i.Title,
+		Number:    i.ID,
+		Ref:       i.Ref().Relative(ctx.Owner, ctx.Repo),
+		URL:       i.URL(),
+		Assignees: i.Assignees,
+		Labels:    i.Labels,
+		Milestone: i.Milestone,
+	}
+
+	if done {
+		data.Checkbox = "[x]"
+	}
+
+	if i.IsAbandoned() && e.NotPlanned == NotPlannedStrike {
+		data.Title = "~~" + data.Title + "~~"
+	}
+
+	if !i.ClosedAt.IsZero() {
+		data.ClosedAt = i.ClosedAt.Format(closedAtLayout)
+	}
+
+	if !i.UpdatedAt.IsZero() {
+		data.UpdatedAt = i.UpdatedAt.Format(closedAtLayout)
+	}
+
+	return ctx.Format.bullet(e.renderer().render(data, spaces, e.formatLineProgress(i)))
 }
 
-func (e *Editor) formatForEmpty(i *Issue, level int, str io.StringWriter, skipMap map[int]bool) error {
+func (e *Editor) formatForEmpty(parent, i *Issue, level int, str io.StringWriter, ctx *editContext) error {
 	if e.MaxLevels > 0 && level >= e.MaxLevels {
 		return errLevelTooDeep
 	}
 
-	if _, ok := skipMap[i.ID]; ok {
-		log.Printf("Skipping processed issue. issue=%v", i.ID)
+	if _, ok := ctx.Processed[issueLink{parent.Ref(), i.Ref()}]; ok {
+		log.Printf("Skipping processed issue. issue=%v", i.Ref())
 		return errAlreadyAdded
 	}
 
-	if _, err := str.WriteString(i.FormatTitle(level*spacesPerLevel) + eol); err != nil {
+	if _, err := str.WriteString(e.formatTitle(i, level*spacesPerLevel, ctx) + eol); err != nil {
 		return err
 	}
 
@@ -85,8 +247,8 @@ func (e *Editor) formatForEmpty(i *Issue, level int, str io.StringWriter, skipMa
 		return nil
 	}
 
-	for _, ci := range i.Children {
-		if err := e.formatForEmpty(ci, level+nextLevel, str, skipMap); err != nil {
+	for _, ci := range e.sorted(i.Children) {
+		if err := e.formatForEmpty(i, ci, level+nextLevel, str, ctx); err != nil {
 			if !isKnownError(err) {
 				return err
 			}
@@ -102,30 +264,73 @@ func (c *editContext) log(s string) {
 }
 
 func (c *editContext) logUpdate(i *Issue) {
-	status := "opened"
-	if i.Status == StatusClosed {
-		status = "closed"
+	// the same issue can be listed under several parents
+	if c.Logged[i.Ref()] {
+		return
 	}
-	c.log(fmt.Sprintf("Updated child issue #%v. New status: %v", i.ID, status))
+	c.Logged[i.Ref()] = true
+
+	c.log(fmt.Sprintf("Updated child issue %v. New status: %v", i.Ref().Relative(c.Owner, c.Repo), i.Status))
 }
 
 func (e *Editor) appendNewSection(i *Issue, ctx *editContext) (string, error) {
 	var str strings.Builder
 
-	str.WriteString(fmt.Sprintf("%s\n\n", issueSectionHead))
-	skipMap := make(map[int]bool)
+	str.WriteString(fmt.Sprintf("%s\n\n", e.heading()))
+	if e.ShowProgress {
+		str.WriteString(e.formatProgress(i) + eol + eol)
+	}
+	if e.ShowEstimates {
+		str.WriteString(e.formatEstimate(i) + eol + eol)
+	}
+	if e.ShowDiagram {
+		str.WriteString(e.formatDiagram(i) + eol)
+	}
+
+	header := e.renderer().header()
+	var items strings.Builder
 
-	for _, ci := range i.Children {
-		if err := e.formatForEmpty(ci, 0 /*level*/, &str, skipMap); err != nil {
-			return "", err
+	if e.GroupBy == GroupNone {
+		writeLines(header, &items)
+		for _, ci := range e.sorted(i.Children) {
+			if err := e.formatForEmpty(i, ci, 0 /*level*/, &items, ctx); err != nil {
+				return "", err
+			}
+		}
+	} else {
+		for n, g := range e.groups(i.Children) {
+			if n > 0 {
+				items.WriteString(eol)
+			}
+			items.WriteString(groupHeading(g.Name) + eol + eol)
+			writeLines(header, &items)
+			for _, ci := range g.Issues {
+				if err := e.formatForEmpty(i, ci, 0 /*level*/, &items, ctx); err != nil {
+					return "", err
+				}
+			}
 		}
 	}
 
+	str.WriteString(e.collapse(items.String(), i.ToMap(), ctx))
+
+	content := str.String()
+	if e.markers() {
+		content = wrapSection(e.heading(), strings.TrimPrefix(content, e.heading()), eol) + eol
+	}
+
 	result := ""
 	if len(i.Body) > 0 {
-		result = strings.TrimRight(i.Body, " \n\t") + "\n\n" + str.String()
+		// new section keeps line endings of the body
+		bodyEOL := detectEOL(i.Body)
+		section := strings.ReplaceAll(content, eol, bodyEOL)
+		if e.Position == SectionTop {
+			result = section + bodyEOL + strings.TrimLeft(i.Body, "\r\n")
+		} else {
+			result = strings.TrimRight(i.Body, " \r\n\t") + bodyEOL + bodyEOL + section
+		}
 	} else {
-		result = str.String()
+		result = content
 	}
 
 	ctx.log(fmt.Sprintf("Appended new block with %v child issue(s)", len(i.Children)))
@@ -133,6 +338,40 @@ func (e *Editor) appendNewSection(i *Issue, ctx *editContext) (string, error) {
 	return result, nil
 }
 
+func trimTrailingBlank(str *strings.Builder) {
+	written := str.String()
+	trimmed := strings.TrimRight(written, eol)
+	if len(trimmed) == len(written) {
+		return
+	}
+
+	str.Reset()
+	str.WriteString(trimmed + eol)
+}
+
+// insertBeforeBlank puts lines before trailing blank lines of the builder
+func insertBeforeBlank(str *strings.Builder, lines string) {
+	if len(lines) == 0 {
+		return
+	}
+
+	written := str.String()
+	trimmed := strings.TrimRight(written, eol)
+	if len(trimmed) == len(written) {
+		str.WriteString(eol + lines)
+		return
+	}
+
+	str.Reset()
+	str.WriteString(trimmed + eol + lines + written[len(trimmed)+len(eol):])
+}
+
+func writeLines(lines []string, str io.StringWriter) {
+	for _, l := range lines {
+		str.WriteString(l + eol)
+	}
+}
+
 func countPrefixSpaces(s string) int {
 	count := 0
 
@@ -146,13 +385,6 @@ func countPrefixSpaces(s string) int {
 	return count
 }
 
-func parseIssueID(s string) (int, error) {
-	hashStart := strings.LastIndex(s, "#")
-	issueStr := strings.TrimSpace(s[hashStart+1:])
-
-	return strconv.Atoi(issueStr)
-}
-
 func isAllWhitespace(s string) bool {
 	for _, ch := range s {
 		if !unicode.IsSpace(ch) {
@@ -169,10 +401,39 @@ func (e *Editor) addMissing(parent *Issue, str io.StringWriter, ctx *editContext
 	}
 
 	log.Printf("Adding missing issues. parent=%v level=%v", parent.ID, parent.Level)
+
+	added := e.addChildren(parent, e.sorted(parent.Children), str, ctx)
+	if added > 0 {
+		ctx.log(fmt.Sprintf("Appended %v new child issue(s) on level %v", added, parent.Level+1))
+	}
+}
+
+// addMissingGrouped renders missing top-level children for every group
+func (e *Editor) addMissingGrouped(parent *Issue, ctx *editContext) map[string]string {
+	log.Printf("Adding missing grouped issues. parent=%v", parent.ID)
+	result := make(map[string]string)
 	added := 0
 
-	for _, ci := range parent.Children {
-		if err := e.formatForEmpty(ci, parent.Level+1, str, ctx.Processed); err != nil {
+	for _, g := range e.groups(parent.Children) {
+		var str strings.Builder
+		added += e.addChildren(parent, g.Issues, &str, ctx)
+		if str.Len() > 0 {
+			result[g.Name] = str.String()
+		}
+	}
+
+	if added > 0 {
+		ctx.log(fmt.Sprintf("Appended %v new child issue(s) on level %v", added, parent.Level+1))
+	}
+
+	return result
+}
+
+func (e *Editor) addChildren(parent *Issue, children []*Issue, str io.StringWriter, ctx *editContext) int {
+	added := 0
+
+	for _, ci := range children {
+		if err := e.formatForEmpty(parent, ci, parent.Level+1, str, ctx); err != nil {
 			if err != errAlreadyAdded {
 				log.Printf("Error while appending new child issues. err=%v", err)
 			}
@@ -181,27 +442,89 @@ func (e *Editor) addMissing(parent *Issue, str io.StringWriter, ctx *editContext
 		}
 	}
 
-	if added > 0 {
-		ctx.log(fmt.Sprintf("Appended %v new child issue(s) on level %v", added, parent.Level+1))
-	}
+	return added
 }
 
-func (e *Editor) updateIssues(i *Issue, start int, ctx *editContext) string {
+// updateIssues updates child issues of the section text that goes after the heading
+func (e *Editor) updateIssues(i *Issue, content string, ctx *editContext) string {
 	var str strings.Builder
 
-	scanner := bufio.NewScanner(strings.NewReader(i.Body[start:]))
+	scanner := bufio.NewScanner(strings.NewReader(ctx.Format.normalize(content)))
 	issueMap := i.ToMap()
+	ctx.Issues = issueMap
 	i.Level = -1
 	ctx.Stack.push(i)
+	skipBlank := false
+	fenced, diagram := false, false
+	var oldDiagram strings.Builder
 
 	for scanner.Scan() {
 		line := scanner.Text()
+
+		// code blocks are not child issues, the diagram is regenerated
+		if fenced || isCodeFence(line) {
+			closing := fenced && isCodeFence(line)
+			if !fenced {
+				fenced = true
+				diagram = e.ShowDiagram && isMermaidFence(line)
+			}
+
+			if diagram {
+				oldDiagram.WriteString(line + eol)
+				skipBlank = closing
+			} else {
+				str.WriteString(line + eol)
+			}
+
+			fenced = !closing
+			continue
+		}
+
+		// details blocks are regenerated after the update
+		if e.collapsing() && isDetailsLine(line) {
+			trimTrailingBlank(&str)
+			skipBlank = true
+			continue
+		}
+
 		if isAllWhitespace(line) {
-			str.WriteString(eol)
+			if !skipBlank {
+				str.WriteString(eol)
+			}
+			skipBlank = false
+			continue
+		}
+		skipBlank = false
+
+		// progress line is regenerated after the update
+		if e.ShowProgress && isProgressLine(line) {
+			skipBlank = true
+			continue
+		}
+
+		if e.ShowEstimates && isEstimateLine(line) {
+			skipBlank = true
+			continue
+		}
+
+		// group heading ends all nested issues of the previous group
+		if e.GroupBy != GroupNone && isGroupHeading(line) {
+			var missing strings.Builder
+			for ctx.Stack.top() != i {
+				if ctx.AddMissing {
+					e.addMissing(ctx.Stack.top(), &missing, ctx)
+				}
+				ctx.Stack.pop()
+			}
+
+			// missing issues go before blank lines of the previous group
+			insertBeforeBlank(&str, missing.String())
+
+			str.WriteString(line + eol)
 			continue
 		}
 
-		spaces := countPrefixSpaces(line)
+		spaces := e.renderer().indent(line)
 		log.Printf("Processing child issue. line=%v spaces=%v", line, spaces)
 
 		if e.MaxLevels > 0 && spaces/2 >= e.MaxLevels {
@@ -210,7 +533,8 @@ func (e *Editor) updateIssues(i *Issue, start int, ctx *editContext) string {
 			continue
 		}
 
-		id, err := parseIssueID(line)
+		managed, annotation := splitAnnotation(line, ctx)
+		id, err := findIssueRef(managed, ctx.Owner, ctx.Repo, ctx.RefFirst)
 		if err != nil {
 			log.Printf("Failed to parse issue ID. line=%v err=%v", line, err)
 			str.WriteString(line + eol)
@@ -218,6 +542,14 @@ func (e *Editor) updateIssues(i *Issue, start int, ctx *editContext) string {
 			continue
 		}
 
+		// references in the text of the section are not pruned
+		if e.Prune != PruneNone && !isItemLine(line) {
+			log.Printf("Skipping reference outside of the list. line=%v", line)
+			str.WriteString(line + eol)
+
+			continue
+		}
+
 		// if next issue is not deeper that the previous, we can unroll the stack
 		for !ctx.Stack.empty() && (ctx.Stack.top().Level >= spaces/2) {
 			if ctx.AddMissing {
@@ -226,6 +558,22 @@ func (e *Editor) updateIssues(i *Issue, start int, ctx *editContext) string {
 			ctx.Stack.pop()
 		}
 
+		if e.Prune != PruneNone {
+			if !e.isLinked(ctx.Stack.top(), id) {
+				e.pruneLine(line, id, &str, ctx)
+				continue
+			}
+
+			if _, ok := issueMap[id]; !ok {
+				// unknown children still define hierarchy for the nested lines
+				log.Printf("Keeping unknown child issue. id=%v", id)
+				ctx.Stack.push(&Issue{ID: id.Number, Owner: id.Owner, Repo: id.Repo, Level: spaces / 2})
+				str.WriteString(line + eol)
+
+				continue
+			}
+		}
+
 		ci, ok := issueMap[id]
 		if !ok {
 			log.Printf("Failed to find child issue by ID. id=%v", id)
@@ -234,23 +582,62 @@ func (e *Editor) updateIssues(i *Issue, start int, ctx *editContext) string {
 			continue
 		}
 
-		log.Printf("Found child issue. id=%v status=%v spaces=%v", ci.ID, ci.Status, ci.Level)
+		log.Printf("Found child issue. id=%v status=%v spaces=%v", id, ci.Status, ci.Level)
 		ci.Level = spaces / 2
+		ctx.Processed[issueLink{ctx.Stack.top().Ref(), id}] = true
 		ctx.Stack.push(ci)
-		ctx.Processed[id] = true
-		title := ci.FormatTitle(spaces)
-		if title != line {
+		title := e.formatTitle(ci, spaces, ctx)
+		// rolled-up progress and the date of the last update are not changes of the child issue itself
+		if stripUpdatedCell(stripLineProgress(title)) != stripUpdatedCell(stripLineProgress(managed)) {
 			ctx.logUpdate(ci)
 		}
-		str.WriteString(title + eol)
+		str.WriteString(title + annotation + eol)
 	}
 
+	var missing map[string]string
+	var tail strings.Builder
 	for ctx.AddMissing && !ctx.Stack.empty() {
-		e.addMissing(ctx.Stack.top(), &str, ctx)
+		if ctx.Stack.top() == i && e.GroupBy != GroupNone {
+			missing = e.addMissingGrouped(i, ctx)
+		} else {
+			e.addMissing(ctx.Stack.top(), &tail, ctx)
+		}
 		ctx.Stack.pop()
 	}
+	// keep new table rows in the same table
+	insertBeforeBlank(&str, tail.String())
+
+	section := str.String()
+	if len(missing) > 0 {
+		section = insertGroups(section, missing, e.renderer().header())
+	}
+	if e.Resort {
+		sorted := strings.Join(e.sortLines(strings.Split(section, eol), issueMap, ctx), eol)
+		if sorted != section {
+			ctx.log("Sorted child issues")
+		}
+		section = sorted
+	}
+
+	section = e.collapse(section, issueMap, ctx)
+
+	if e.ShowDiagram {
+		newDiagram := e.formatDiagram(i)
+		if newDiagram != oldDiagram.String() {
+			ctx.log("Updated hierarchy diagram")
+		}
+		section = insertBlock(section, strings.TrimRight(newDiagram, eol))
+	}
+
+	if e.ShowEstimates {
+		section = insertBlock(section, e.formatEstimate(i))
+	}
+
+	if e.ShowProgress {
+		section = insertBlock(section, e.formatProgress(i))
+	}
 
-	return i.Body[:start] + str.String()
+	return ctx.Format.denormalize(section)
 }
 
 func (e *Editor) Update(i *Issue, addMissing bool) (string, []string, error) {
@@ -258,15 +645,28 @@ func (e *Editor) Update(i *Issue, addMissing bool) (string, []string, error) {
 		return "", nil, nil
 	}
 
-	if len(i.Children) == 0 {
+	sec, found := e.findSection(i.Body)
+
+	// children that were not fetched in this run are still counted
+	if found && i.Listed == nil {
+		i.Listed = e.ListedChildren(i, i.Body)
+	}
+
+	// in prune mode former parents without children are still updated
+	if len(i.Children) == 0 && (e.Prune == PruneNone || !found) {
 		return i.Body, nil, nil
 	}
 
 	ctx := &editContext{
 		ChangeLog:  make([]string, 0),
 		AddMissing: addMissing,
-		Processed:  make(map[int]bool),
+		Processed:  make(map[issueLink]bool),
+		Logged:     make(map[IssueRef]bool),
 		Stack:      &stack{data: make([]*Issue, 0)},
+		Owner:      i.Owner,
+		Repo:       i.Repo,
+		RefFirst:   e.renderer().refFirst(),
+		Format:     defaultFormat,
 	}
 
 	if len(i.Body) == 0 {
@@ -274,12 +674,26 @@ func (e *Editor) Update(i *Issue, addMissing bool) (string, []string, error) {
 		return body, ctx.ChangeLog, err
 	}
 
-	sectionStart := strings.LastIndex(i.Body, issueSectionHead)
-	if sectionStart == -1 {
+	if !found {
 		body, err := e.appendNewSection(i, ctx)
 		return body, ctx.ChangeLog, err
 	}
 
-	body := e.updateIssues(i, sectionStart+len(issueSectionHead), ctx)
+	ctx.Format = detectFormat(i.Body[sec.Start:sec.End])
+	content := e.updateIssues(i, i.Body[sec.Content:sec.ContentEnd], ctx)
+
+	if !sec.Marked && !e.markers() {
+		return i.Body[:sec.Content] + content + i.Body[sec.End:], ctx.ChangeLog, nil
+	}
+
+	rest := i.Body[sec.End:]
+	if !sec.Marked {
+		log.Printf("Adding markers to child issues section. issue=%v", i.Ref())
+		if len(rest) > 0 {
+			rest = ctx.Format.EOL + ctx.Format.EOL + rest
+		}
+	}
+
+	body := i.Body[:sec.Start] + wrapSection(e.heading(), content, ctx.Format.EOL) + rest
 	return body, ctx.ChangeLog, nil
 }
@@ -3,7 +3,9 @@ package main
 import (
 	"fmt"
 	"log"
+	"strings"
 	"testing"
+	"time"
 )
 
 type I = Issue
@@ -34,7 +36,7 @@ func createIssuesImpl(children, level, recurse, id int, status IssueStatus) *Iss
 		issue.Children[i].ID = issueID
 		issue.Children[i].Status = status
 
-		log.Printf("Created issue: %v", issue.Children[i].FormatTitle(0))
+		log.Printf("Created issue: %v", issue.Children[i].Ref())
 	}
 	return issue
 }
@@ -563,4 +565,1414 @@ efgh
 	}
 
 	EditAppendSuite(t, issue, body, expected, 1 /*changes*/)
-}
\ No newline at end of file
+}
+
+func TestAddForeignChildren(t *testing.T) {
+	body := "abcd"
+	expected := `abcd
+
+### Child issues:
+
+- [ ] Local child #10
+- [ ] Foreign child service/api#7
+`
+
+	issue := &Issue{
+		ID:    1,
+		Owner: "org",
+		Repo:  "planning",
+		Children: []*Issue{
+			&Issue{ID: 10, Owner: "org", Repo: "planning", Title: "Local child"},
+			&Issue{ID: 7, Owner: "service", Repo: "api", Title: "Foreign child"},
+		},
+	}
+
+	EditSuite(t, issue, body, expected, 1 /*changes*/)
+}
+
+func TestUpdateForeignChildren(t *testing.T) {
+	body := `abcd
+
+### Child issues:
+
+- [ ] Foreign child service/api#7
+- [ ] Same number in other repo service/web#7
+`
+	expected := `abcd
+
+### Child issues:
+
+- [x] Foreign child service/api#7
+- [ ] Same number in other repo service/web#7
+`
+
+	issue := &Issue{
+		ID:    1,
+		Owner: "org",
+		Repo:  "planning",
+		Children: []*Issue{
+			&Issue{ID: 7, Owner: "service", Repo: "api", Title: "Foreign child", Status: StatusClosed},
+		},
+	}
+
+	EditSuite(t, issue, body, expected, 1 /*changes*/)
+}
+
+func createDiamond(status IssueStatus) *Issue {
+	shared := &Issue{ID: 100, Title: "Shared", Status: status}
+	return &Issue{
+		ID:    1,
+		Title: "Root",
+		Children: []*Issue{
+			&Issue{ID: 10, Title: "Left", Children: []*Issue{shared}},
+			&Issue{ID: 11, Title: "Right", Children: []*Issue{shared}},
+		},
+	}
+}
+
+func TestAddSharedChild(t *testing.T) {
+	body := ""
+	expected := `### Child issues:
+
+- [ ] Left #10
+  - [ ] Shared #100
+- [ ] Right #11
+  - [ ] Shared #100
+`
+
+	EditSuite(t, createDiamond(StatusOpened), body, expected, 1 /*changes*/)
+}
+
+func TestUpdateSharedChild(t *testing.T) {
+	body := `### Child issues:
+
+- [ ] Left #10
+  - [ ] Shared #100
+- [ ] Right #11
+  - [ ] Shared #100
+`
+	expected := `### Child issues:
+
+- [ ] Left #10
+  - [x] Shared #100
+- [ ] Right #11
+  - [x] Shared #100
+`
+
+	EditSuite(t, createDiamond(StatusClosed), body, expected, 1 /*changes*/)
+}
+
+func TestAppendSharedChild(t *testing.T) {
+	body := `### Child issues:
+
+- [ ] Left #10
+  - [ ] Shared #100
+- [ ] Right #11
+`
+	expected := `### Child issues:
+
+- [ ] Left #10
+  - [ ] Shared #100
+- [ ] Right #11
+  - [ ] Shared #100
+`
+
+	EditAppendSuite(t, createDiamond(StatusOpened), body, expected, 1 /*changes*/)
+}
+
+func pruneEditor(mode PruneMode, linked ...int) *Editor {
+	return &Editor{
+		Prune: mode,
+		Linked: func(parent, child IssueRef) bool {
+			for _, l := range linked {
+				if child.Number == l {
+					return true
+				}
+			}
+			return false
+		},
+	}
+}
+
+func TestPruneRemoveStaleChild(t *testing.T) {
+	body := `abcd
+
+### Child issues:
+
+- [ ] Child Issue id(10) level(1) #10
+- [ ] Moved away #20
+  - [ ] Child of moved away #200
+- [ ] Old but linked #30
+  - [ ] Child of old #300
+`
+
+	expected := `abcd
+
+### Child issues:
+
+- [ ] Child Issue id(10) level(1) #10
+- [ ] Old but linked #30
+  - [ ] Child of old #300
+`
+
+	issue := createIssues(
+		1 /*children*/, 0 /*level*/, 0 /*recurse*/, StatusOpened)
+	EditorSuite(t, pruneEditor(PruneRemove, 30, 300),
+		issue, false /*add missing*/, body, expected, 2 /*changes*/)
+}
+
+func TestPruneKeepsTextReferences(t *testing.T) {
+	body := `abcd
+
+### Child issues:
+
+See #5 for context.
+
+- [ ] Child Issue id(10) level(1) #10
+- [ ] Moved away #20
+`
+
+	expected := `abcd
+
+### Child issues:
+
+See #5 for context.
+
+- [ ] Child Issue id(10) level(1) #10
+`
+
+	issue := createIssues(
+		1 /*children*/, 0 /*level*/, 0 /*recurse*/, StatusOpened)
+	EditorSuite(t, pruneEditor(PruneRemove),
+		issue, false /*add missing*/, body, expected, 1 /*changes*/)
+}
+
+func TestPruneStrikeStaleChild(t *testing.T) {
+	body := `abcd
+
+### Child issues:
+
+- [ ] Child Issue id(10) level(1) #10
+- [x] Moved away #20
+- [ ] ~~Struck before #30~~
+`
+
+	expected := `abcd
+
+### Child issues:
+
+- [ ] Child Issue id(10) level(1) #10
+- [x] ~~Moved away #20~~
+- [ ] ~~Struck before #30~~
+`
+
+	issue := createIssues(
+		1 /*children*/, 0 /*level*/, 0 /*recurse*/, StatusOpened)
+	EditorSuite(t, pruneEditor(PruneStrike),
+		issue, false /*add missing*/, body, expected, 1 /*changes*/)
+}
+
+func TestPruneMovedChildInHierarchy(t *testing.T) {
+	body := `### Child issues:
+
+- [ ] Child Issue id(10) level(1) #10
+  - [ ] Child Issue id(110) level(2) #110
+- [ ] Child Issue id(11) level(1) #11
+`
+
+	expected := `### Child issues:
+
+- [ ] Child Issue id(10) level(1) #10
+- [ ] Child Issue id(11) level(1) #11
+  - [ ] Child Issue id(110) level(2) #110
+`
+
+	issue := createIssues(
+		2 /*children*/, 0 /*level*/, 0 /*recurse*/, StatusOpened)
+	issue.Children[1].Children = []*Issue{&Issue{ID: 110, Title: "Child Issue id(110) level(2)"}}
+	EditorSuite(t, pruneEditor(PruneRemove),
+		issue, true /*add missing*/, body, expected, 2 /*changes*/)
+}
+
+func TestPruneFormerParent(t *testing.T) {
+	body := `abcd
+
+### Child issues:
+
+- [ ] Moved away #20
+`
+
+	expected := `abcd
+
+### Child issues:
+
+`
+
+	issue := &Issue{ID: 1, Title: "Former parent"}
+	EditorSuite(t, pruneEditor(PruneRemove),
+		issue, true /*add missing*/, body, expected, 1 /*changes*/)
+}
+
+func createPullRequests() *Issue {
+	return &Issue{
+		ID:    1,
+		Title: "Parent",
+		Children: []*Issue{
+			&Issue{ID: 10, Title: "Issue", Status: StatusClosed},
+			&Issue{ID: 11, Title: "Open", Status: StatusOpened, PullRequest: true},
+			&Issue{ID: 12, Title: "Draft", Status: StatusDraft, PullRequest: true},
+			&Issue{ID: 13, Title: "Merged", Status: StatusMerged, PullRequest: true},
+			&Issue{ID: 14, Title: "Closed", Status: StatusClosed, PullRequest: true},
+		},
+	}
+}
+
+func TestAddPullRequests(t *testing.T) {
+	body := ""
+	expected := `### Child issues:
+
+- [x] Issue #10
+- [ ] [PR] Open #11
+- [ ] [draft PR] Draft #12
+- [x] [merged PR] Merged #13
+- [x] [closed PR] Closed #14
+`
+
+	EditSuite(t, createPullRequests(), body, expected, 1 /*changes*/)
+}
+
+func TestUpdatePullRequestsOnlyMerged(t *testing.T) {
+	body := `### Child issues:
+
+- [x] Issue #10
+- [ ] [PR] Open #11
+- [ ] [PR] Draft #12
+- [ ] [PR] Merged #13
+- [x] [closed PR] Closed #14
+`
+	expected := `### Child issues:
+
+- [x] Issue #10
+- [ ] [PR] Open #11
+- [ ] [draft PR] Draft #12
+- [x] [merged PR] Merged #13
+- [ ] [closed PR] Closed #14
+`
+
+	EditorSuite(t, &Editor{OnlyMergedDone: true},
+		createPullRequests(), false /*add missing*/, body, expected, 3 /*changes*/)
+}
+
+func createNotPlanned() *Issue {
+	return &Issue{
+		ID:    1,
+		Title: "Parent",
+		Children: []*Issue{
+			&Issue{ID: 10, Title: "Completed", Status: StatusClosed},
+			&Issue{ID: 11, Title: "Abandoned", Status: StatusNotPlanned},
+			&Issue{ID: 12, Title: "Locked", Status: StatusLocked},
+		},
+	}
+}
+
+func TestAddNotPlanned(t *testing.T) {
+	body := ""
+	expected := `### Child issues:
+
+- [x] Completed #10
+- [x] ~~Abandoned~~ #11
+- [ ] [locked] Locked #12
+`
+
+	EditSuite(t, createNotPlanned(), body, expected, 1 /*changes*/)
+}
+
+func TestUpdateNotPlannedCheck(t *testing.T) {
+	body := `### Child issues:
+
+- [x] Completed #10
+- [ ] Abandoned #11
+- [ ] Locked #12
+`
+	expected := `### Child issues:
+
+- [x] Completed #10
+- [x] Abandoned #11
+- [ ] [locked] Locked #12
+`
+
+	EditorSuite(t, &Editor{NotPlanned: NotPlannedCheck},
+		createNotPlanned(), false /*add missing*/, body, expected, 2 /*changes*/)
+}
+
+func TestPruneStrikeNotPlanned(t *testing.T) {
+	body := `### Child issues:
+
+- [x] ~~Abandoned~~ #20
+`
+	expected := `### Child issues:
+
+- [x] ~~Abandoned #20~~
+`
+
+	issue := createIssues(
+		0 /*children*/, 0 /*level*/, 0 /*recurse*/, StatusOpened)
+	EditorSuite(t, pruneEditor(PruneStrike),
+		issue, false /*add missing*/, body, expected, 1 /*changes*/)
+}
+
+func TestAddProgress(t *testing.T) {
+	body := "abcd"
+	expected := `abcd
+
+### Child issues:
+
+Progress: 2/6 (33%)
+
+- [ ] Child Issue id(10) level(1) #10 (1/2)
+  - [x] Child Issue id(100) level(2) #100
+  - [ ] Child Issue id(101) level(2) #101
+- [ ] Child Issue id(11) level(1) #11 (1/2)
+  - [x] Child Issue id(110) level(2) #110
+  - [ ] Child Issue id(111) level(2) #111
+`
+	issue := createIssues(
+		2 /*children*/, 0 /*level*/, 1 /*recurse*/, StatusOpened)
+	issue.Children[0].Children[0].Status = StatusClosed
+	issue.Children[1].Children[0].Status = StatusClosed
+
+	EditorSuite(t, &Editor{ShowProgress: true},
+		issue, false /*add missing*/, body, expected, 1 /*changes*/)
+}
+
+func TestUpdateProgress(t *testing.T) {
+	body := `abcd
+
+### Child issues:
+
+Progress: 0/4 (0%)
+
+- [ ] Child Issue id(10) level(1) #10 (0/1)
+  - [ ] Child Issue id(100) level(2) #100
+- [ ] Child Issue id(11) level(1) #11
+- [ ] ~~Child Issue id(12) level(1)~~ #12
+`
+	expected := `abcd
+
+### Child issues:
+
+Progress: 2/3 (66%)
+
+- [ ] Child Issue id(10) level(1) #10 (1/1)
+  - [x] Child Issue id(100) level(2) #100
+- [x] Child Issue id(11) level(1) #11
+- [x] ~~Child Issue id(12) level(1)~~ #12
+`
+	issue := createIssues(
+		3 /*children*/, 0 /*level*/, 0 /*recurse*/, StatusOpened)
+	issue.Children[0].Children = []*Issue{&Issue{ID: 100, Title: "Child Issue id(100) level(2)", Status: StatusClosed}}
+	issue.Children[1].Status = StatusClosed
+	issue.Children[2].Status = StatusNotPlanned
+
+	EditorSuite(t, &Editor{ShowProgress: true},
+		issue, false /*add missing*/, body, expected, 3 /*changes*/)
+}
+
+func TestUpdateProgressUnfetched(t *testing.T) {
+	body := `### Child issues:
+
+- [ ] Old child #10
+- [x] Done child #12
+- [ ] ~~Stale child~~ #13
+- [ ] New child #11
+`
+	expected := `### Child issues:
+
+Progress: 2/3 (66%)
+
+- [ ] Old child #10
+- [x] Done child #12
+- [ ] ~~Stale child~~ #13
+- [x] New child #11
+`
+
+	// only the child updated in this run is fetched
+	issue := &Issue{ID: 1, Title: "Parent", Children: []*Issue{
+		&Issue{ID: 11, Title: "New child", Status: StatusClosed},
+	}}
+
+	EditorSuite(t, &Editor{ShowProgress: true},
+		issue, false /*add missing*/, body, expected, 1 /*changes*/)
+}
+
+func TestKeepUserProgressLine(t *testing.T) {
+	body := `### Child issues:
+
+- [x] Child Issue id(10) level(1) #10
+- [ ] Child Issue id(11) level(1) #11
+
+Progress: waiting for the design review
+`
+	expected := `### Child issues:
+
+Progress: 1/2 (50%)
+
+- [x] Child Issue id(10) level(1) #10
+- [ ] Child Issue id(11) level(1) #11
+
+Progress: waiting for the design review
+`
+	issue := createIssues(
+		2 /*children*/, 0 /*level*/, 0 /*recurse*/, StatusOpened)
+	issue.Children[0].Status = StatusClosed
+
+	EditorSuite(t, &Editor{ShowProgress: true},
+		issue, false /*add missing*/, body, expected, 0 /*changes*/)
+}
+
+func TestInsertProgressBar(t *testing.T) {
+	body := `### Child issues:
+
+- [x] Child Issue id(10) level(1) #10
+- [ ] Child Issue id(11) level(1) #11
+`
+	expected := `### Child issues:
+
+Progress: █████░░░░░ 1/2 (50%)
+
+- [x] Child Issue id(10) level(1) #10
+- [ ] Child Issue id(11) level(1) #11
+`
+	issue := createIssues(
+		2 /*children*/, 0 /*level*/, 0 /*recurse*/, StatusOpened)
+	issue.Children[0].Status = StatusClosed
+
+	EditorSuite(t, &Editor{ShowProgress: true, ProgressBar: true},
+		issue, false /*add missing*/, body, expected, 0 /*changes*/)
+}
+
+func templateEditor(t *testing.T, text string) *Editor {
+	tmpl, err := NewLineTemplate(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &Editor{LineTemplate: tmpl}
+}
+
+func createTemplateIssues() *Issue {
+	return &Issue{
+		ID:    1,
+		Title: "Parent",
+		Children: []*Issue{
+			&Issue{ID: 10, Title: "Fix login", Status: StatusClosed, Assignees: []string{"alice", "bob"},
+				Labels: []string{"bug"}, ClosedAt: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)},
+			&Issue{ID: 11, Title: "Follow-up of #10", Milestone: "v1.0"},
+		},
+	}
+}
+
+func TestAddTemplate(t *testing.T) {
+	body := ""
+	expected := `### Child issues:
+
+- {{✅}} #10 Fix login (@alice, @bob) [bug] 2024-05-01
+- {{⬜}} #11 Follow-up of #10 v1.0
+`
+
+	e := templateEditor(t, `- {{"{{"}}{{.Emoji}}{{"}}"}} {{.Ref}} {{.Title}}`+
+		`{{if .Assignees}} (@{{join .Assignees ", @"}}){{end}}`+
+		`{{if .Labels}} [{{join .Labels ", "}}]{{end}} {{.Milestone}}{{.ClosedAt}}`)
+	EditorSuite(t, e, createTemplateIssues(), false /*add missing*/, body, expected, 1 /*changes*/)
+}
+
+func TestUpdateTemplateReferenceFirst(t *testing.T) {
+	body := `### Child issues:
+
+- [ ] #10 Fix login
+- [ ] #11 Follow-up of #10
+`
+	expected := `### Child issues:
+
+- [x] #10 Fix login
+- [ ] #11 Follow-up of #10
+`
+
+	e := templateEditor(t, `- {{.Checkbox}} {{.Ref}} {{.Title}}`)
+	EditorSuite(t, e, createTemplateIssues(), false /*add missing*/, body, expected, 1 /*changes*/)
+}
+
+func TestUpdateTemplateLink(t *testing.T) {
+	body := `### Child issues:
+
+- [ ] [Fix login](https://github.com/owner/repo/issues/10)
+`
+	expected := `### Child issues:
+
+- [x] [Fix login](https://github.com/owner/repo/issues/10)
+`
+
+	issue := createTemplateIssues()
+	issue.Owner, issue.Repo = "owner", "repo"
+	for _, ci := range issue.Children {
+		ci.Owner, ci.Repo = "owner", "repo"
+	}
+
+	e := templateEditor(t, `- {{.Checkbox}} [{{.Title}}]({{.URL}})`)
+	EditorSuite(t, e, issue, false /*add missing*/, body, expected, 1 /*changes*/)
+}
+
+func TestUpdateTemplateURLFirst(t *testing.T) {
+	body := `### Child issues:
+
+- [ ] https://github.com/owner/repo/issues/10 Fix login
+- [ ] https://github.com/owner/repo/issues/11 Follow-up of #10
+`
+	expected := `### Child issues:
+
+- [x] https://github.com/owner/repo/issues/10 Fix login
+- [ ] https://github.com/owner/repo/issues/11 Follow-up of #10
+`
+
+	issue := createTemplateIssues()
+	issue.Owner, issue.Repo = "owner", "repo"
+	for _, ci := range issue.Children {
+		ci.Owner, ci.Repo = "owner", "repo"
+	}
+
+	e := templateEditor(t, `- {{.Checkbox}} {{.URL}} {{.Title}}`)
+	EditorSuite(t, e, issue, false /*add missing*/, body, expected, 1 /*changes*/)
+}
+
+func TestLineTemplateWithoutReference(t *testing.T) {
+	tests := []struct {
+		text  string
+		valid bool
+	}{
+		{`- {{.Checkbox}} {{.Title}} ({{.Number}})`, false},
+		{`- {{.Checkbox}} {{.Title}}`, false},
+		{`- {{.Checkbox}} {{.Title}} #{{.Number}}`, true},
+		{`- {{.Checkbox}} [{{.Title}}]({{.URL}})`, true},
+		{`- {{.Checkbox}} {{.Ref}} {{.Title}}`, true},
+	}
+
+	for _, tt := range tests {
+		_, err := NewLineTemplate(tt.text)
+		if (err == nil) != tt.valid {
+			t.Errorf("Template validation does not match. template=%v err=%v", tt.text, err)
+		}
+	}
+}
+
+func createSortIssues() *Issue {
+	return &Issue{
+		ID:    1,
+		Title: "Parent",
+		Children: []*Issue{
+			&Issue{ID: 12, Title: "b", Status: StatusClosed, Labels: []string{"P2"}},
+			&Issue{ID: 10, Title: "C", Labels: []string{"P1"},
+				MilestoneDue: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)},
+			&Issue{ID: 11, Title: "a", Labels: []string{"P0"},
+				MilestoneDue: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+				Children: []*Issue{
+					&Issue{ID: 111, Title: "z"},
+					&Issue{ID: 110, Title: "y", Status: StatusClosed},
+				}},
+		},
+	}
+}
+
+func TestAddSorted(t *testing.T) {
+	tests := []struct {
+		sortBy   SortPolicy
+		expected []int
+	}{
+		{SortByNumber, []int{10, 11, 110, 111, 12}},
+		{SortByStatus, []int{10, 11, 111, 110, 12}},
+		{SortByTitle, []int{11, 110, 111, 12, 10}},
+		{SortByPriority, []int{11, 110, 111, 10, 12}},
+		{SortByMilestone, []int{11, 110, 111, 10, 12}},
+	}
+
+	for _, tt := range tests {
+		e := &Editor{SortBy: tt.sortBy, PriorityLabels: []string{"p0", "p1", "p2"}}
+		issue := createSortIssues()
+		body, _, err := e.Update(issue, false /*add missing*/)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ids := make([]int, 0)
+		for _, line := range strings.Split(body, "\n") {
+			if id, err := findIssueRef(line, "", "", false); err == nil {
+				ids = append(ids, id.Number)
+			}
+		}
+
+		if fmt.Sprint(ids) != fmt.Sprint(tt.expected) {
+			t.Errorf("Order does not match. sort=%v actual=%v expected=%v", tt.sortBy, ids, tt.expected)
+		}
+	}
+}
+
+func TestResortExisting(t *testing.T) {
+	body := `### Child issues:
+
+- [x] b #12
+- [ ] a #11
+  - [ ] z #111
+  - [x] y #110
+- [ ] Unknown #5
+- [ ] C #10
+`
+	expected := `### Child issues:
+
+- [ ] C #10
+- [ ] a #11
+  - [ ] z #111
+  - [x] y #110
+- [x] b #12
+- [ ] Unknown #5
+`
+
+	EditorSuite(t, &Editor{SortBy: SortByStatus, Resort: true},
+		createSortIssues(), true /*add missing*/, body, expected, 1 /*changes*/)
+}
+
+func TestAppendSortedMissing(t *testing.T) {
+	body := `### Child issues:
+
+- [ ] a #11
+`
+	expected := `### Child issues:
+
+- [ ] a #11
+  - [x] y #110
+  - [ ] z #111
+- [ ] C #10
+- [x] b #12
+`
+
+	EditAppendSuite(t, createSortIssues(), body, expected, 2 /*changes*/)
+}
+
+func createGroupIssues() *Issue {
+	return &Issue{
+		ID:    1,
+		Title: "Parent",
+		Children: []*Issue{
+			&Issue{ID: 10, Title: "API", Labels: []string{"bug", "area/api"}},
+			&Issue{ID: 11, Title: "UI", Labels: []string{"area/ui"},
+				Children: []*Issue{
+					&Issue{ID: 110, Title: "Button", Labels: []string{"area/api"}},
+				}},
+			&Issue{ID: 12, Title: "Docs"},
+			&Issue{ID: 13, Title: "Client", Labels: []string{"area/api"}, Status: StatusClosed},
+		},
+	}
+}
+
+func TestAddGrouped(t *testing.T) {
+	expected := `### Child issues:
+
+#### area/api
+
+- [ ] API #10
+- [x] Client #13
+
+#### area/ui
+
+- [ ] UI #11
+  - [ ] Button #110
+
+#### Other
+
+- [ ] Docs #12
+`
+
+	e := &Editor{GroupBy: GroupByLabel, GroupLabelPrefix: "area/"}
+	EditorSuite(t, e, createGroupIssues(), false /*add missing*/, "", expected, 1 /*changes*/)
+}
+
+func TestAppendGroupedMissing(t *testing.T) {
+	body := `### Child issues:
+
+#### area/api
+
+- [ ] API #10
+
+#### Other
+
+- [ ] Docs #12
+`
+	expected := `### Child issues:
+
+#### area/api
+
+- [ ] API #10
+- [x] Client #13
+
+#### area/ui
+
+- [ ] UI #11
+  - [ ] Button #110
+
+#### Other
+
+- [ ] Docs #12
+`
+
+	e := &Editor{GroupBy: GroupByLabel, GroupLabelPrefix: "area/"}
+	EditorSuite(t, e, createGroupIssues(), true /*add missing*/, body, expected, 1 /*changes*/)
+}
+
+func TestUpdateGroupedNested(t *testing.T) {
+	body := `### Child issues:
+
+#### area/ui
+
+- [ ] UI #11
+
+#### Other
+
+- [x] Docs #12
+`
+	expected := `### Child issues:
+
+#### area/ui
+
+- [ ] UI #11
+  - [ ] Button #110
+
+#### area/api
+
+- [ ] API #10
+- [x] Client #13
+
+#### Other
+
+- [ ] Docs #12
+`
+
+	e := &Editor{GroupBy: GroupByLabel, GroupLabelPrefix: "area/"}
+	EditorSuite(t, e, createGroupIssues(), true /*add missing*/, body, expected, 3 /*changes*/)
+}
+
+func TestGroupOf(t *testing.T) {
+	i := &Issue{Labels: []string{"bug", "Area/ui"}, Milestone: "v1.0", Assignees: []string{"zed", "amy"}}
+
+	tests := []struct {
+		groupBy  string
+		expected string
+	}{
+		{"label:area/", "Area/ui"},
+		{"label", "Area/ui"},
+		{"milestone", "v1.0"},
+		{"assignee", "@amy"},
+		{"label:kind/", groupOther},
+	}
+
+	for _, tt := range tests {
+		e := &Editor{}
+		e.GroupBy, e.GroupLabelPrefix = parseGroupPolicy(tt.groupBy)
+		if g := e.groupOf(i); g != tt.expected {
+			t.Errorf("Group does not match. group_by=%v actual=%v expected=%v", tt.groupBy, g, tt.expected)
+		}
+	}
+}
+
+func createTableIssues() *Issue {
+	return &Issue{
+		ID:    1,
+		Title: "Parent",
+		Children: []*Issue{
+			&Issue{ID: 10, Title: "API | backend", Assignees: []string{"amy"}, Labels: []string{"bug", "api"},
+				Children: []*Issue{
+					&Issue{ID: 100, Title: "Endpoint", Status: StatusClosed, UpdatedAt: time.Date(2024, 5, 2, 10, 0, 0, 0, time.UTC)},
+				}},
+			&Issue{ID: 11, Title: "UI"},
+		},
+	}
+}
+
+func TestAddTable(t *testing.T) {
+	expected := `### Child issues:
+
+| Status | Issue | Title | Assignees | Labels | Updated |
+|--------|-------|-------|-----------|--------|---------|
+| ⬜ opened | #10 | API \| backend | @amy | bug, api | |
+| ✅ closed | #100 | &emsp;Endpoint | | | 2024-05-02 |
+| ⬜ opened | #11 | UI | | | |
+`
+
+	EditorSuite(t, &Editor{Render: RenderTable}, createTableIssues(), false /*add missing*/, "", expected, 1 /*changes*/)
+}
+
+func TestUpdateTable(t *testing.T) {
+	body := `### Child issues:
+
+| Status | Issue | Title | Assignees | Labels | Updated |
+|--------|-------|-------|-----------|--------|---------|
+| ⬜ opened | #10 | API \| backend | @amy | bug, api | |
+| ⬜ opened | #100 | &emsp;Endpoint | | | |
+
+`
+	expected := `### Child issues:
+
+Progress: 1/3 (33%)
+
+| Status | Issue | Title | Assignees | Labels | Updated |
+|--------|-------|-------|-----------|--------|---------|
+| ⬜ opened | #10 | API \| backend (1/1) | @amy | bug, api | |
+| ✅ closed | #100 | &emsp;Endpoint | | | 2024-05-02 |
+| ⬜ opened | #11 | UI | | | |
+
+`
+
+	e := &Editor{Render: RenderTable, ShowProgress: true}
+	EditorSuite(t, e, createTableIssues(), true /*add missing*/, body, expected, 2 /*changes*/)
+}
+
+func TestUpdateTableDate(t *testing.T) {
+	body := `### Child issues:
+
+| Status | Issue | Title | Assignees | Labels | Updated |
+|--------|-------|-------|-----------|--------|---------|
+| ⬜ opened | #10 | API \| backend | @amy | bug, api | |
+| ✅ closed | #100 | &emsp;Endpoint | | | 2024-04-01 |
+| ⬜ opened | #11 | UI | | | |
+`
+	expected := `### Child issues:
+
+| Status | Issue | Title | Assignees | Labels | Updated |
+|--------|-------|-------|-----------|--------|---------|
+| ⬜ opened | #10 | API \| backend | @amy | bug, api | |
+| ✅ closed | #100 | &emsp;Endpoint | | | 2024-05-02 |
+| ⬜ opened | #11 | UI | | | |
+`
+
+	// the date alone is not a change of the child issue
+	EditorSuite(t, &Editor{Render: RenderTable}, createTableIssues(), true /*add missing*/, body, expected, 0 /*changes*/)
+}
+
+func TestPruneTable(t *testing.T) {
+	body := `### Child issues:
+
+| Status | Issue | Title | Assignees | Labels | Updated |
+|--------|-------|-------|-----------|--------|---------|
+| ⬜ opened | #11 | UI | | | |
+| ⬜ opened | #12 | &emsp;Stale | | | |
+`
+	expected := `### Child issues:
+
+| Status | Issue | Title | Assignees | Labels | Updated |
+|--------|-------|-------|-----------|--------|---------|
+| ⬜ opened | #11 | UI | | | |
+| ⬜ opened | #12 | &emsp;~~Stale~~ | | | |
+`
+
+	issue := createTableIssues()
+	issue.Children = issue.Children[1:]
+	e := &Editor{Render: RenderTable, Prune: PruneStrike, Linked: func(parent, child IssueRef) bool { return false }}
+	EditorSuite(t, e, issue, false /*add missing*/, body, expected, 1 /*changes*/)
+}
+
+func createDiagramIssues() *Issue {
+	shared := &Issue{ID: 12, Title: `Shared "core"`, Status: StatusClosed}
+
+	return &Issue{
+		ID:    1,
+		Title: "Parent",
+		Children: []*Issue{
+			&Issue{ID: 10, Title: "API", Children: []*Issue{shared}},
+			&Issue{ID: 5, Owner: "other", Repo: "repo", Title: "Lib", Children: []*Issue{shared}},
+		},
+	}
+}
+
+const diagramSection = "### Child issues:\n\n" +
+	"```mermaid\n" +
+	"flowchart TD\n" +
+	"  i1[\"#1 Parent\"]\n" +
+	"  i10[\"#10 API\"]\n" +
+	"  i12[\"#12 Shared #quot;core#quot;\"]\n" +
+	"  i_other_repo_5[\"other/repo#5 Lib\"]\n" +
+	"  i1 --> i10\n" +
+	"  i10 --> i12\n" +
+	"  i1 --> i_other_repo_5\n" +
+	"  i_other_repo_5 --> i12\n" +
+	"  click i1 href \"https://github.com///issues/1\"\n" +
+	"  class i1 opened\n" +
+	"  click i10 href \"https://github.com///issues/10\"\n" +
+	"  class i10 opened\n" +
+	"  click i12 href \"https://github.com///issues/12\"\n" +
+	"  class i12 done\n" +
+	"  click i_other_repo_5 href \"https://github.com/other/repo/issues/5\"\n" +
+	"  class i_other_repo_5 opened\n" +
+	"  classDef opened fill:#ddf4ff,stroke:#0969da\n" +
+	"  classDef done fill:#dafbe1,stroke:#1a7f37\n" +
+	"```\n\n" +
+	"- [ ] API #10\n" +
+	"  - [x] Shared \"core\" #12\n" +
+	"- [ ] Lib other/repo#5\n" +
+	"  - [x] Shared \"core\" #12\n"
+
+func TestAddDiagram(t *testing.T) {
+	EditorSuite(t, &Editor{ShowDiagram: true}, createDiagramIssues(), false, /*add missing*/
+		"", diagramSection, 1 /*changes*/)
+}
+
+func TestUpdateDiagramUnchanged(t *testing.T) {
+	EditorSuite(t, &Editor{ShowDiagram: true}, createDiagramIssues(), true, /*add missing*/
+		diagramSection, diagramSection, 0 /*changes*/)
+}
+
+func TestUpdateDiagram(t *testing.T) {
+	body := strings.Replace(diagramSection, "  class i10 opened\n", "  class i10 done\n", 1)
+	body = strings.Replace(body, "- [ ] API #10", "- [x] API #10", 1)
+
+	EditorSuite(t, &Editor{ShowDiagram: true}, createDiagramIssues(), true, /*add missing*/
+		body, diagramSection, 2 /*changes*/)
+}
+
+func TestKeepCodeBlock(t *testing.T) {
+	body := "### Child issues:\n\n```\n- [ ] Not a child #10\n```\n\n- [x] Child Issue id(10) level(1) #10\n"
+	expected := "### Child issues:\n\n```\n- [ ] Not a child #10\n```\n\n- [ ] Child Issue id(10) level(1) #10\n"
+
+	issue := createIssues(1 /*children*/, 0 /*level*/, 0 /*recurse*/, StatusOpened)
+	EditSuite(t, issue, body, expected, 1 /*changes*/)
+}
+
+func createCollapseIssues() *Issue {
+	return &Issue{
+		ID:    1,
+		Title: "Parent",
+		Children: []*Issue{
+			&Issue{ID: 10, Title: "API", Children: []*Issue{
+				&Issue{ID: 100, Title: "A <b>", Status: StatusClosed},
+				&Issue{ID: 101, Title: "B"},
+				&Issue{ID: 102, Title: "C"},
+			}},
+			&Issue{ID: 11, Title: "UI"},
+			&Issue{ID: 12, Title: "Docs"},
+		},
+	}
+}
+
+const collapseSection = `### Child issues:
+
+- [ ] API #10
+  <details><summary>API #10 (1/3)</summary>
+
+  - [x] A <b> #100
+  - [ ] B #101
+  <details><summary>Show 1 more</summary>
+
+  - [ ] C #102
+
+  </details>
+
+  </details>
+
+- [ ] UI #11
+<details><summary>Show 1 more</summary>
+
+- [ ] Docs #12
+
+</details>
+`
+
+func TestAddCollapsed(t *testing.T) {
+	e := &Editor{CollapseNested: true, CollapseAfter: 2}
+	EditorSuite(t, e, createCollapseIssues(), false /*add missing*/, "", collapseSection, 1 /*changes*/)
+}
+
+func TestUpdateCollapsedUnchanged(t *testing.T) {
+	e := &Editor{CollapseNested: true, CollapseAfter: 2}
+	EditorSuite(t, e, createCollapseIssues(), true /*add missing*/, collapseSection, collapseSection, 0 /*changes*/)
+}
+
+func TestUpdateCollapsed(t *testing.T) {
+	body := `### Child issues:
+
+- [ ] API #10
+  <details><summary>API #10 (0/3)</summary>
+
+  - [ ] A <b> #100
+  - [ ] B #101
+
+  </details>
+
+- [ ] UI #11
+`
+
+	e := &Editor{CollapseNested: true, CollapseAfter: 2}
+	EditorSuite(t, e, createCollapseIssues(), true /*add missing*/, body, collapseSection, 3 /*changes*/)
+}
+
+func TestSplitAnnotation(t *testing.T) {
+	tests := []struct {
+		line       string
+		managed    string
+		annotation string
+	}{
+		{"- [ ] Fix login #42 — blocked on vendor", "- [ ] Fix login #42", " — blocked on vendor"},
+		{"- [ ] Fix login #42 -- see #55", "- [ ] Fix login #42", " -- see #55"},
+		{"- [ ] Fix login #42 (1/2) — note", "- [ ] Fix login #42 (1/2)", " — note"},
+		{"- [x] ~~Fix login #42~~ — dropped", "- [x] ~~Fix login #42~~", " — dropped"},
+		{"- [ ] [Fix](https://github.com/o/r/issues/42) — note", "- [ ] [Fix](https://github.com/o/r/issues/42)", " — note"},
+		{"- [ ] Fix — login #42", "- [ ] Fix — login #42", ""},
+		{"- [ ] Fix login #42", "- [ ] Fix login #42", ""},
+		{"- [ ] Port #12 — v2 API #40", "- [ ] Port #12 — v2 API #40", ""},
+		{"- [ ] Port #12 — v2 API #40 — see #12", "- [ ] Port #12 — v2 API #40", " — see #12"},
+		{"- [ ] Old #12 — title #40 -- note", "- [ ] Old #12 — title #40", " -- note"},
+	}
+
+	ctx := &editContext{Issues: map[IssueRef]*Issue{
+		NewIssueRef("", "", 40): &Issue{ID: 40, Title: "Port #12 — v2 API"},
+	}}
+
+	for _, tt := range tests {
+		managed, annotation := splitAnnotation(tt.line, ctx)
+		if managed != tt.managed || annotation != tt.annotation {
+			t.Errorf("Annotation does not match. line=%v managed=%v annotation=%v", tt.line, managed, annotation)
+		}
+	}
+}
+
+func TestUpdateKeepsAnnotation(t *testing.T) {
+	body := `### Child issues:
+
+- [x] Child Issue id(10) level(1) #10 — blocked on vendor, see #55
+- [ ] Child Issue id(11) level(1) #11 -- ask @amy
+`
+	expected := `### Child issues:
+
+- [ ] Child Issue id(10) level(1) #10 — blocked on vendor, see #55
+- [ ] Child Issue id(11) level(1) #11 -- ask @amy
+`
+
+	issue := createIssues(2 /*children*/, 0 /*level*/, 0 /*recurse*/, StatusOpened)
+	EditSuite(t, issue, body, expected, 1 /*changes*/)
+}
+
+func TestUpdateTitleWithDelimiter(t *testing.T) {
+	body := `### Child issues:
+
+- [ ] Port #12 — v2 API #40
+- [ ] Port #12 — v1 API #41 — see #12
+`
+	expected := `### Child issues:
+
+- [x] Port #12 — v2 API #40
+- [ ] Port #12 — v3 API #41 — see #12
+`
+
+	issue := &Issue{ID: 1, Title: "Parent", Children: []*Issue{
+		&Issue{ID: 40, Title: "Port #12 — v2 API", Status: StatusClosed},
+		&Issue{ID: 41, Title: "Port #12 — v3 API"},
+	}}
+	EditorSuite(t, &Editor{}, issue, false /*add missing*/, body, expected, 2 /*changes*/)
+}
+
+func TestPruneKeepsAnnotation(t *testing.T) {
+	body := `### Child issues:
+
+- [ ] Moved #12 — moved to another epic
+`
+	expected := `### Child issues:
+
+- [ ] ~~Moved #12~~ — moved to another epic
+`
+
+	issue := createIssues(0 /*children*/, 0 /*level*/, 0 /*recurse*/, StatusOpened)
+	e := &Editor{Prune: PruneStrike, Linked: func(parent, child IssueRef) bool { return false }}
+	EditorSuite(t, e, issue, false /*add missing*/, body, expected, 1 /*changes*/)
+}
+
+func TestUpdateKeepsFormat(t *testing.T) {
+	body := "Description\r\n\r\n### Child issues:\r\n\r\n" +
+		"* [ ] Child Issue id(10) level(1) #10\r\n" +
+		"    * [ ] Child Issue id(100) level(2) #100\r\n" +
+		"    * [x] Child Issue id(101) level(2) #101\r\n" +
+		"* [x] Child Issue id(11) level(1) #11\r\n"
+	expected := "Description\r\n\r\n### Child issues:\r\n\r\n" +
+		"* [ ] Child Issue id(10) level(1) #10\r\n" +
+		"    * [ ] Child Issue id(100) level(2) #100\r\n" +
+		"    * [ ] Child Issue id(101) level(2) #101\r\n" +
+		"* [ ] Child Issue id(11) level(1) #11\r\n" +
+		"    * [ ] Child Issue id(110) level(2) #110\r\n" +
+		"    * [ ] Child Issue id(111) level(2) #111\r\n"
+
+	issue := createIssues(2 /*children*/, 0 /*level*/, 1 /*recurse*/, StatusOpened)
+	EditAppendSuite(t, issue, body, expected, 3 /*changes*/)
+}
+
+func TestUpdateKeepsTabs(t *testing.T) {
+	body := "### Child issues:\n\n" +
+		"+ [ ] Child Issue id(10) level(1) #10\n" +
+		"\t+ [x] Child Issue id(100) level(2) #100\n" +
+		"\t\t+ [ ] Unknown #1000\n" +
+		"+ [ ] Child Issue id(11) level(1) #11\n"
+	expected := "### Child issues:\n\n" +
+		"+ [ ] Child Issue id(10) level(1) #10\n" +
+		"\t+ [ ] Child Issue id(100) level(2) #100\n" +
+		"\t\t+ [ ] Unknown #1000\n" +
+		"\t+ [ ] Child Issue id(101) level(2) #101\n" +
+		"+ [ ] Child Issue id(11) level(1) #11\n"
+
+	issue := createIssues(2 /*children*/, 0 /*level*/, 1 /*recurse*/, StatusOpened)
+	issue.Children[1].Children = nil
+	EditAppendSuite(t, issue, body, expected, 2 /*changes*/)
+}
+
+func TestAddSectionKeepsLineEndings(t *testing.T) {
+	body := "Description\r\nMore text\r\n"
+	expected := "Description\r\nMore text\r\n\r\n### Child issues:\r\n\r\n" +
+		"- [ ] Child Issue id(10) level(1) #10\r\n"
+
+	issue := createIssues(1 /*children*/, 0 /*level*/, 0 /*recurse*/, StatusOpened)
+	EditSuite(t, issue, body, expected, 1 /*changes*/)
+}
+
+func TestAddMarkedSectionTop(t *testing.T) {
+	body := "Description"
+	expected := `<!-- child-issues:start -->
+## Tasks
+
+- [ ] Child Issue id(10) level(1) #10
+<!-- child-issues:end -->
+
+Description`
+
+	issue := createIssues(1 /*children*/, 0 /*level*/, 0 /*recurse*/, StatusOpened)
+	e := &Editor{Markers: true, Heading: "## Tasks", Position: SectionTop}
+	EditorSuite(t, e, issue, false /*add missing*/, body, expected, 1 /*changes*/)
+}
+
+func TestUpdateSectionTopWithoutMarkers(t *testing.T) {
+	body := "Description\n- [ ] Not a child #11"
+	expected := `<!-- child-issues:start -->
+### Child issues:
+
+- [ ] Child Issue id(10) level(1) #10
+<!-- child-issues:end -->
+
+Description
+- [ ] Not a child #11`
+
+	issue := createIssues(1 /*children*/, 0 /*level*/, 0 /*recurse*/, StatusOpened)
+	e := &Editor{Position: SectionTop, Prune: PruneRemove, Linked: func(parent, child IssueRef) bool { return false }}
+	EditorSuite(t, e, issue, true /*add missing*/, body, expected, 1 /*changes*/)
+
+	updated := `<!-- child-issues:start -->
+### Child issues:
+
+- [x] Child Issue id(10) level(1) #10
+- [ ] Child Issue id(12) level(1) #12
+<!-- child-issues:end -->
+
+Description
+- [ ] Not a child #11`
+
+	issue.Children[0].Status = StatusClosed
+	issue.Children = append(issue.Children, &Issue{ID: 12, Title: "Child Issue id(12) level(1)"})
+	EditorSuite(t, e, issue, true /*add missing*/, expected, updated, 2 /*changes*/)
+}
+
+func TestUpdateMarkedSection(t *testing.T) {
+	body := `Description
+
+<!-- child-issues:start -->
+### Child issues:
+
+- [x] Child Issue id(10) level(1) #10
+<!-- child-issues:end -->
+
+Notes below the list
+- [ ] Not a child #11
+`
+	expected := `Description
+
+<!-- child-issues:start -->
+## Tasks
+
+- [ ] Child Issue id(10) level(1) #10
+- [ ] Child Issue id(11) level(1) #11
+<!-- child-issues:end -->
+
+Notes below the list
+- [ ] Not a child #11
+`
+
+	issue := createIssues(2 /*children*/, 0 /*level*/, 0 /*recurse*/, StatusOpened)
+	e := &Editor{Markers: true, Heading: "## Tasks"}
+	EditorSuite(t, e, issue, true /*add missing*/, body, expected, 2 /*changes*/)
+}
+
+func TestMigrateSection(t *testing.T) {
+	body := `Description
+
+### Child issues:
+
+- [x] Child Issue id(10) level(1) #10
+
+#### Subheading is a part of the section
+
+## Notes
+
+Text below the list
+`
+	expected := `Description
+
+<!-- child-issues:start -->
+### Child issues:
+
+- [ ] Child Issue id(10) level(1) #10
+
+#### Subheading is a part of the section
+<!-- child-issues:end -->
+
+## Notes
+
+Text below the list
+`
+
+	issue := createIssues(1 /*children*/, 0 /*level*/, 0 /*recurse*/, StatusOpened)
+	EditorSuite(t, &Editor{Markers: true}, issue, true /*add missing*/, body, expected, 1 /*changes*/)
+}
+
+func TestFindSection(t *testing.T) {
+	tests := []struct {
+		body    string
+		content string
+		found   bool
+	}{
+		{"text", "", false},
+		{"text\n### Child issues:\n- a\n## Next", "\n- a\n", true},
+		{"<!-- child-issues:start -->\n### Child issues:\n- a\n<!-- child-issues:end -->\ntext", "\n- a\n", true},
+		{"<!-- child-issues:start -->\n- a\n<!-- child-issues:end -->", "\n- a\n", true},
+		{"<!-- child-issues:start -->\r\n### Child issues:\r\n- a\r\n<!-- child-issues:end -->", "\r\n- a\r\n", true},
+	}
+
+	e := &Editor{Markers: true}
+	for _, tt := range tests {
+		s, found := e.findSection(tt.body)
+		if found != tt.found {
+			t.Errorf("Section is not found. body=%q", tt.body)
+			continue
+		}
+
+		if content := tt.body[s.Content:s.ContentEnd]; found && content != tt.content {
+			t.Errorf("Section content does not match. body=%q actual=%q expected=%q", tt.body, content, tt.content)
+		}
+	}
+}
+
+func createEstimateIssues() *Issue {
+	return &Issue{
+		ID:    1,
+		Title: "Parent",
+		Children: []*Issue{
+			&Issue{ID: 10, Title: "API", Estimate: 100, Estimated: true, Children: []*Issue{
+				&Issue{ID: 100, Title: "A", Status: StatusClosed, Estimate: 3, Estimated: true},
+				&Issue{ID: 101, Title: "B", Estimate: 5, Estimated: true},
+				&Issue{ID: 102, Title: "C", Status: StatusNotPlanned, Estimate: 8, Estimated: true},
+			}},
+			&Issue{ID: 11, Title: "UI", Estimate: 0.5, Estimated: true},
+			&Issue{ID: 12, Title: "Docs"},
+		},
+	}
+}
+
+func TestAddEstimates(t *testing.T) {
+	expected := `### Child issues:
+
+Progress: 1/5 (20%)
+
+Estimate: 8.5 total, 5.5 remaining
+
+- [ ] API #10 (1/2, 5 of 8 left)
+  - [x] A #100
+  - [ ] B #101
+  - [x] ~~C~~ #102
+- [ ] UI #11
+- [ ] Docs #12
+`
+
+	e := &Editor{ShowProgress: true, ShowEstimates: true}
+	EditorSuite(t, e, createEstimateIssues(), false /*add missing*/, "", expected, 1 /*changes*/)
+}
+
+func TestUpdateEstimates(t *testing.T) {
+	body := `### Child issues:
+
+Estimate: 1 total, 1 remaining
+
+Estimate: two sprints, see the design doc
+
+- [ ] API #10 (3 of 3 left) — owned by @amy
+  - [ ] A #100
+  - [ ] B #101
+- [ ] UI #11
+- [ ] Docs #12
+`
+	expected := `### Child issues:
+
+Estimate: 8.5 total, 5.5 remaining
+
+Estimate: two sprints, see the design doc
+
+- [ ] API #10 (5 of 8 left) — owned by @amy
+  - [x] A #100
+  - [ ] B #101
+  - [x] ~~C~~ #102
+- [ ] UI #11
+- [ ] Docs #12
+`
+
+	e := &Editor{ShowEstimates: true}
+	EditorSuite(t, e, createEstimateIssues(), true /*add missing*/, body, expected, 2 /*changes*/)
+}
+
+func TestWeightedProgress(t *testing.T) {
+	expected := `### Child issues:
+
+Progress: ███░░░░░░░ 1/5 (31%)
+
+- [ ] API #10 (1/2)
+  - [x] A #100
+  - [ ] B #101
+  - [x] ~~C~~ #102
+- [ ] UI #11
+- [ ] Docs #12
+`
+
+	e := &Editor{ShowProgress: true, ProgressBar: true, WeightedProgress: true}
+	EditorSuite(t, e, createEstimateIssues(), false /*add missing*/, "", expected, 1 /*changes*/)
+}
+
+func TestWeightedProgressUnfetched(t *testing.T) {
+	body := `### Child issues:
+
+- [ ] Old child #10
+- [x] Done child #12
+- [ ] New child #11
+`
+	expected := `### Child issues:
+
+Progress: 1/3 (16%)
+
+- [ ] Old child #10
+- [x] Done child #12
+- [ ] New child #11
+`
+
+	// unfetched children weigh as children without estimate
+	issue := &Issue{ID: 1, Title: "Parent", Children: []*Issue{
+		&Issue{ID: 11, Title: "New child", Estimate: 4, Estimated: true},
+	}}
+
+	e := &Editor{ShowProgress: true, WeightedProgress: true}
+	EditorSuite(t, e, issue, false /*add missing*/, body, expected, 0 /*changes*/)
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	estimatePrefix         = "Estimate:"
+	defaultEstimateLabels  = "size/XS=1,size/S=2,size/M=3,size/L=5,size/XL=8,points/*"
+	hoursPerDay            = 8
+	daysPerWeek            = 5
+	estimateWildcardSuffix = "*"
+)
+
+// matches "Estimate: 3d" line in the issue body
+var estimateRegexp = regexp.MustCompile(`(?mi)^\s*estimate:\s*(\d+(?:\.\d+)?)\s*([hdw]?)\s*$`)
+
+// matches estimate line generated by formatEstimate
+var estimateLineRegexp = regexp.MustCompile(`^` + estimatePrefix + ` [\d.]+ total, [\d.]+ remaining$`)
+
+// EstimateMapping converts size labels to numbers
+type EstimateMapping struct {
+	Labels map[string]float64
+	// labels with these prefixes have the number after the prefix
+	Prefixes []string
+}
+
+// parseEstimateMapping parses "size/S=1,size/M=3,points/*"
+func parseEstimateMapping(s string) *EstimateMapping {
+	m := &EstimateMapping{Labels: make(map[string]float64)}
+
+	for _, item := range splitList(s) {
+		if strings.HasSuffix(item, estimateWildcardSuffix) {
+			m.Prefixes = append(m.Prefixes, strings.ToLower(strings.TrimSuffix(item, estimateWildcardSuffix)))
+			continue
+		}
+
+		parts := strings.SplitN(item, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		if v, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64); err == nil {
+			m.Labels[strings.ToLower(strings.TrimSpace(parts[0]))] = v
+		}
+	}
+
+	return m
+}
+
+// Estimate returns estimate of the issue from the body or from labels,
+// hours and weeks in the body are converted to days
+func (m *EstimateMapping) Estimate(i *Issue) (float64, bool) {
+	if match := estimateRegexp.FindStringSubmatch(i.Body); match != nil {
+		v, _ := strconv.ParseFloat(match[1], 64)
+		switch strings.ToLower(match[2]) {
+		case "h":
+			v /= hoursPerDay
+		case "w":
+			v *= daysPerWeek
+		}
+		return v, true
+	}
+
+	for _, l := range sortedCopy(i.Labels) {
+		label := strings.ToLower(l)
+		if v, ok := m.Labels[label]; ok {
+			return v, true
+		}
+
+		for _, p := range m.Prefixes {
+			if !strings.HasPrefix(label, p) {
+				continue
+			}
+			if v, err := strconv.ParseFloat(label[len(p):], 64); err == nil {
+				return v, true
+			}
+		}
+	}
+
+	return 0, false
+}
+
+type estimate struct {
+	Total     float64
+	Remaining float64
+}
+
+func formatNumber(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// estimateOf sums estimates of all unique descendants without children,
+// sub-parents count with their own estimate only if none of their descendants is estimated
+func (e *Editor) estimateOf(i *Issue) estimate {
+	est, _ := e.subtreeEstimate(i, make(map[IssueRef]bool))
+
+	return est
+}
+
+// subtreeEstimate returns estimate of the descendants and whether any of them is estimated
+func (e *Editor) subtreeEstimate(i *Issue, seen map[IssueRef]bool) (estimate, bool) {
+	est, found := estimate{}, false
+
+	for _, ci := range i.Children {
+		// same issue can be reachable from several parents
+		if seen[ci.Ref()] || ci.IsAbandoned() {
+			continue
+		}
+		seen[ci.Ref()] = true
+
+		sub, ok := e.subtreeEstimate(ci, seen)
+		if !ok && ci.Estimated {
+			sub, ok = estimate{Total: ci.Estimate}, true
+			if !ci.IsDone(e.OnlyMergedDone) {
+				sub.Remaining = ci.Estimate
+			}
+		}
+
+		if ok {
+			est.Total += sub.Total
+			est.Remaining += sub.Remaining
+			found = true
+		}
+	}
+
+	return est, found
+}
+
+func (e *Editor) formatEstimate(i *Issue) string {
+	est := e.estimateOf(i)
+
+	return fmt.Sprintf("%s %s total, %s remaining", estimatePrefix, formatNumber(est.Total), formatNumber(est.Remaining))
+}
+
+// formatLineEstimate returns rolled-up estimate for issues with estimated children
+func (e *Editor) formatLineEstimate(i *Issue) string {
+	if !e.ShowEstimates || len(i.Children) == 0 {
+		return ""
+	}
+
+	est := e.estimateOf(i)
+	if est.Total == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("%s of %s left", formatNumber(est.Remaining), formatNumber(est.Total))
+}
+
+func isEstimateLine(line string) bool {
+	return estimateLineRegexp.MatchString(strings.TrimSpace(line))
+}
+
+// SetEstimates parses estimates of all issues in the tree
+func (t *tree) SetEstimates(m *EstimateMapping) {
+	for _, i := range t.issues {
+		i.Estimate, i.Estimated = m.Estimate(i)
+	}
+}
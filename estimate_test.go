@@ -0,0 +1,60 @@
+package main
+
+import "testing"
+
+func TestEstimateMapping(t *testing.T) {
+	m := parseEstimateMapping("size/S=1, size/M=3,points/*,broken")
+
+	tests := []struct {
+		issue     *Issue
+		expected  float64
+		estimated bool
+	}{
+		{&Issue{Labels: []string{"Size/M"}}, 3, true},
+		{&Issue{Labels: []string{"bug", "points/5"}}, 5, true},
+		{&Issue{Labels: []string{"points/x"}}, 0, false},
+		{&Issue{Body: "Text\nEstimate: 2.5d\n", Labels: []string{"size/S"}}, 2.5, true},
+		{&Issue{Body: "estimate: 4h"}, 0.5, true},
+		{&Issue{Body: "Estimate: 2w"}, 10, true},
+		{&Issue{Body: "Estimate: soon"}, 0, false},
+	}
+
+	for _, tt := range tests {
+		v, ok := m.Estimate(tt.issue)
+		if v != tt.expected || ok != tt.estimated {
+			t.Errorf("Estimate does not match. issue=%v actual=%v expected=%v", tt.issue.Labels, v, tt.expected)
+		}
+	}
+}
+
+func TestEstimateOf(t *testing.T) {
+	story := &Issue{ID: 10, Estimate: 5, Estimated: true, Children: []*Issue{
+		&Issue{ID: 100},
+		&Issue{ID: 101, Status: StatusClosed},
+	}}
+	sized := &Issue{ID: 11, Estimate: 8, Estimated: true, Children: []*Issue{
+		&Issue{ID: 110, Estimate: 2, Estimated: true, Status: StatusClosed},
+		&Issue{ID: 111},
+	}}
+	dropped := &Issue{ID: 12, Status: StatusNotPlanned, Estimate: 3, Estimated: true}
+	shared := &Issue{ID: 13, Estimate: 1, Estimated: true}
+	sized.Children = append(sized.Children, shared)
+	epic := &Issue{ID: 1, Estimate: 100, Estimated: true, Children: []*Issue{story, sized, dropped, shared}}
+
+	tests := []struct {
+		issue    *Issue
+		expected estimate
+	}{
+		// story without sized tasks counts with its own estimate
+		{epic, estimate{Total: 8, Remaining: 6}},
+		{story, estimate{}},
+		{sized, estimate{Total: 3, Remaining: 1}},
+	}
+
+	e := &Editor{}
+	for _, tt := range tests {
+		if est := e.estimateOf(tt.issue); est != tt.expected {
+			t.Errorf("Estimate does not match. issue=%v actual=%v expected=%v", tt.issue.ID, est, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"strings"
+)
+
+const crlf = "\r\n"
+
+// sectionFormat keeps the style of the existing child section
+type sectionFormat struct {
+	Bullet string
+	// indentation of one level
+	Indent string
+	EOL    string
+}
+
+var defaultFormat = sectionFormat{Bullet: "-", Indent: "  ", EOL: eol}
+
+func isFormattedLine(line string) bool {
+	return listItemRegexp.MatchString(line) || isDetailsLine(line)
+}
+
+// formattedLines calls f for lines outside of code blocks that are affected by format
+func formattedLines(lines []string, f func(n int, line string)) {
+	fenced := false
+
+	for n, line := range lines {
+		if isCodeFence(line) {
+			fenced = !fenced
+			continue
+		}
+
+		if !fenced && isFormattedLine(line) {
+			f(n, line)
+		}
+	}
+}
+
+func detectEOL(s string) string {
+	if strings.Contains(s, crlf) {
+		return crlf
+	}
+
+	return eol
+}
+
+func leadingWhitespace(line string) string {
+	return line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+}
+
+func detectFormat(section string) sectionFormat {
+	format := defaultFormat
+	format.EOL = detectEOL(section)
+
+	lines := strings.Split(strings.ReplaceAll(section, crlf, eol), eol)
+	bullet, indent := "", ""
+
+	formattedLines(lines, func(n int, line string) {
+		m := listItemRegexp.FindStringSubmatch(line)
+		if m == nil {
+			return
+		}
+
+		if len(bullet) == 0 {
+			bullet = strings.TrimSpace(m[1])[:1]
+		}
+
+		ws := leadingWhitespace(line)
+		switch {
+		case len(ws) == 0:
+		case strings.Contains(ws, "\t"):
+			indent = "\t"
+		case indent != "\t" && (len(indent) == 0 || len(ws) < len(indent)):
+			indent = ws
+		}
+	})
+
+	if len(bullet) > 0 {
+		format.Bullet = bullet
+	}
+
+	if len(indent) > 0 {
+		format.Indent = indent
+	}
+
+	return format
+}
+
+func (f sectionFormat) canonicalIndent() bool {
+	return f.Indent == defaultFormat.Indent
+}
+
+// normalize converts the section to "\n" line endings and two spaces per level
+func (f sectionFormat) normalize(section string) string {
+	lines := strings.Split(strings.ReplaceAll(section, crlf, eol), eol)
+
+	if !f.canonicalIndent() {
+		formattedLines(lines, func(n int, line string) {
+			ws := leadingWhitespace(line)
+			level := len(ws) / len(f.Indent)
+			lines[n] = strings.Repeat(" ", level*spacesPerLevel) + line[len(ws):]
+		})
+	}
+
+	return strings.Join(lines, eol)
+}
+
+// denormalize restores the indentation and line endings of the section
+func (f sectionFormat) denormalize(section string) string {
+	lines := strings.Split(section, eol)
+
+	if !f.canonicalIndent() {
+		formattedLines(lines, func(n int, line string) {
+			ws := leadingWhitespace(line)
+			level := len(ws) / spacesPerLevel
+			lines[n] = strings.Repeat(f.Indent, level) + line[len(ws):]
+		})
+	}
+
+	return strings.Join(lines, f.EOL)
+}
+
+// bullet replaces the default bullet of the rendered line
+func (f sectionFormat) bullet(line string) string {
+	spaces := countPrefixSpaces(line)
+	if f.Bullet == defaultFormat.Bullet || !strings.HasPrefix(line[spaces:], "- ") {
+		return line
+	}
+
+	return line[:spaces] + f.Bullet + line[spaces+1:]
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+const (
+	groupHeadingPrefix = "#### "
+	groupOther         = "Other"
+)
+
+type GroupPolicy int
+
+const (
+	GroupNone GroupPolicy = iota
+	GroupByLabel
+	GroupByMilestone
+	GroupByAssignee
+)
+
+// parseGroupPolicy parses "label:<prefix>", "milestone" or "assignee"
+func parseGroupPolicy(s string) (GroupPolicy, string) {
+	s = strings.TrimSpace(s)
+	parts := strings.SplitN(s, ":", 2)
+
+	switch strings.ToLower(strings.TrimSpace(parts[0])) {
+	case "label":
+		prefix := ""
+		if len(parts) == 2 {
+			prefix = strings.TrimSpace(parts[1])
+		}
+		return GroupByLabel, prefix
+	case "milestone":
+		return GroupByMilestone, ""
+	case "assignee":
+		return GroupByAssignee, ""
+	default:
+		return GroupNone, ""
+	}
+}
+
+type issueGroup struct {
+	Name   string
+	Issues []*Issue
+}
+
+func sortedCopy(s []string) []string {
+	result := make([]string, len(s))
+	copy(result, s)
+	sort.Strings(result)
+
+	return result
+}
+
+func (e *Editor) groupOf(i *Issue) string {
+	switch e.GroupBy {
+	case GroupByLabel:
+		prefix := strings.ToLower(e.GroupLabelPrefix)
+		for _, l := range sortedCopy(i.Labels) {
+			if strings.HasPrefix(strings.ToLower(l), prefix) {
+				return l
+			}
+		}
+	case GroupByMilestone:
+		if len(i.Milestone) > 0 {
+			return i.Milestone
+		}
+	case GroupByAssignee:
+		if len(i.Assignees) > 0 {
+			return "@" + sortedCopy(i.Assignees)[0]
+		}
+	}
+
+	return groupOther
+}
+
+// groupLess orders groups by name, issues without group go last
+func groupLess(a, b string) bool {
+	if (a == groupOther) != (b == groupOther) {
+		return b == groupOther
+	}
+
+	return a < b
+}
+
+// groups splits sorted issues into sorted groups
+func (e *Editor) groups(issues []*Issue) []*issueGroup {
+	byName := make(map[string]*issueGroup)
+	result := make([]*issueGroup, 0)
+
+	for _, i := range e.sorted(issues) {
+		name := e.groupOf(i)
+		g, ok := byName[name]
+		if !ok {
+			g = &issueGroup{Name: name}
+			byName[name] = g
+			result = append(result, g)
+		}
+		g.Issues = append(g.Issues, i)
+	}
+
+	sort.Slice(result, func(i, j int) bool { return groupLess(result[i].Name, result[j].Name) })
+
+	return result
+}
+
+func isGroupHeading(line string) bool {
+	return strings.HasPrefix(line, groupHeadingPrefix)
+}
+
+func groupHeading(name string) string {
+	return groupHeadingPrefix + name
+}
+
+func findGroupHeading(lines []string, name string) int {
+	for i, l := range lines {
+		if isGroupHeading(l) && strings.TrimSpace(l) == groupHeading(name) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// insertGroups puts rendered child issue lines at the end of their groups,
+// missing groups are added with the header before the group of issues without group
+func insertGroups(section string, missing map[string]string, header []string) string {
+	names := make([]string, 0, len(missing))
+	for name := range missing {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return groupLess(names[i], names[j]) })
+
+	lines := strings.Split(section, eol)
+
+	for _, name := range names {
+		items := strings.Split(strings.TrimRight(missing[name], eol), eol)
+		insert := make([]string, 0, len(items)+3)
+		at := findGroupHeading(lines, name)
+
+		if at == -1 {
+			insert = append(insert, groupHeading(name), "")
+			insert = append(insert, header...)
+			insert = append(insert, items...)
+
+			at = findGroupHeading(lines, groupOther)
+			if at == -1 {
+				// append the group to the end of the section
+				for len(lines) > 0 && isAllWhitespace(lines[len(lines)-1]) {
+					lines = lines[:len(lines)-1]
+				}
+				insert = append([]string{""}, insert...)
+				insert = append(insert, "")
+				at = len(lines)
+			} else {
+				insert = append(insert, "")
+			}
+		} else {
+			end := at + 1
+			for end < len(lines) && !isGroupHeading(lines[end]) {
+				end++
+			}
+
+			last := end - 1
+			for last > at && isAllWhitespace(lines[last]) {
+				last--
+			}
+
+			if last == at {
+				insert = append(insert, "")
+			}
+			insert = append(insert, items...)
+			at = last + 1
+		}
+
+		result := make([]string, 0, len(lines)+len(insert))
+		result = append(result, lines[:at]...)
+		result = append(result, insert...)
+		result = append(result, lines[at:]...)
+		lines = result
+	}
+
+	return strings.Join(lines, eol)
+}
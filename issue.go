@@ -3,6 +3,10 @@ package main
 import (
 	"errors"
 	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/google/go-github/v73/github"
 )
@@ -13,72 +17,314 @@ const (
 	StatusOpened IssueStatus = iota
 	StatusClosed
 	StatusLocked
+	// pull request statuses
+	StatusDraft
+	StatusMerged
+	// issue was closed with "not planned" state reason
+	StatusNotPlanned
 )
 
+func (s IssueStatus) String() string {
+	switch s {
+	case StatusClosed:
+		return "closed"
+	case StatusLocked:
+		return "locked"
+	case StatusDraft:
+		return "draft"
+	case StatusMerged:
+		return "merged"
+	case StatusNotPlanned:
+		return "not planned"
+	default:
+		return "opened"
+	}
+}
+
 var (
-	errIssueNotFound = errors.New("issue not found")
+	errIssueNotFound  = errors.New("issue not found")
+	errWrongRefSyntax = errors.New("wrong issue reference syntax")
 )
 
+// matches "#123", "owner/repo#123" and "https://github.com/owner/repo/issues/123"
+var issueRefRegexp = regexp.MustCompile(
+	`https://github\.com/([\w.-]+)/([\w.-]+)/(?:issues|pull)/(\d{1,10})|(?:([\w.-]+)/([\w.-]+))?#(\d{1,10})`)
+
+// IssueRef is a repository-qualified issue number
+type IssueRef struct {
+	Owner  string
+	Repo   string
+	Number int
+}
+
+func NewIssueRef(owner, repo string, number int) IssueRef {
+	return IssueRef{
+		Owner:  strings.ToLower(owner),
+		Repo:   strings.ToLower(repo),
+		Number: number,
+	}
+}
+
+func (r IssueRef) SameRepo(owner, repo string) bool {
+	return r.Owner == strings.ToLower(owner) && r.Repo == strings.ToLower(repo)
+}
+
+// Relative formats the reference as "#N" within owner/repo and as "owner/repo#N" elsewhere
+func (r IssueRef) Relative(owner, repo string) string {
+	if r.SameRepo(owner, repo) {
+		return fmt.Sprintf("#%v", r.Number)
+	}
+
+	return r.String()
+}
+
+func (r IssueRef) String() string {
+	if r.Owner == "" && r.Repo == "" {
+		return fmt.Sprintf("#%v", r.Number)
+	}
+
+	return fmt.Sprintf("%s/%s#%v", r.Owner, r.Repo, r.Number)
+}
+
+func (r IssueRef) Less(other IssueRef) bool {
+	if r.Owner != other.Owner {
+		return r.Owner < other.Owner
+	}
+
+	if r.Repo != other.Repo {
+		return r.Repo < other.Repo
+	}
+
+	return r.Number < other.Number
+}
+
+// issueLink is an edge between parent and child in the issue hierarchy
+type issueLink struct {
+	parent IssueRef
+	child  IssueRef
+}
+
+func refFromMatch(m []string, owner, repo string) (IssueRef, error) {
+	switch {
+	case m[3] != "":
+		n, err := strconv.Atoi(m[3])
+		return NewIssueRef(m[1], m[2], n), err
+	case m[4] != "":
+		n, err := strconv.Atoi(m[6])
+		return NewIssueRef(m[4], m[5], n), err
+	default:
+		n, err := strconv.Atoi(m[6])
+		return NewIssueRef(owner, repo, n), err
+	}
+}
+
+// parseIssueRef parses a whole string as an issue reference,
+// bare "#N" references are resolved against owner/repo
+func parseIssueRef(s, owner, repo string) (IssueRef, error) {
+	s = strings.TrimSpace(s)
+
+	m := issueRefRegexp.FindStringSubmatch(s)
+	if m == nil || len(m[0]) != len(s) {
+		return IssueRef{}, errWrongRefSyntax
+	}
+
+	return refFromMatch(m, owner, repo)
+}
+
+// findIssueRef returns the first or the last issue reference found in the string
+func findIssueRef(s, owner, repo string, first bool) (IssueRef, error) {
+	matches := issueRefRegexp.FindAllStringSubmatch(s, -1)
+	if len(matches) == 0 {
+		return IssueRef{}, errWrongRefSyntax
+	}
+
+	if first {
+		return refFromMatch(matches[0], owner, repo)
+	}
+
+	return refFromMatch(matches[len(matches)-1], owner, repo)
+}
+
 type Issue struct {
-	ID       int
-	Title    string
-	Body     string
-	Status   IssueStatus
-	Children []*Issue
-	Level    int
+	ID    int
+	Owner string
+	Repo  string
+	// GitHub-wide issue ID used by the sub-issues API
+	DatabaseID int64
+	Title      string
+	Body       string
+	Status     IssueStatus
+	Children   []*Issue
+	Level      int
+	// issue is a pull request
+	PullRequest  bool
+	HTMLURL      string
+	Labels       []string
+	Assignees    []string
+	Milestone    string
+	MilestoneDue time.Time
+	ClosedAt     time.Time
+	UpdatedAt    time.Time
+	// estimate is parsed from the body or size labels
+	Estimate  float64
+	Estimated bool
+	// children listed in the existing section and whether they are shown as done
+	Listed map[IssueRef]bool
+	// issue is only listed in the section of its parent and was not fetched
+	Unfetched bool
 }
 
 func (i *Issue) IsOpened() bool {
-	return i.Status == StatusOpened
+	return i.Status == StatusOpened || i.Status == StatusDraft
 }
 
 func (i *Issue) IsClosed() bool {
-	return i.Status == StatusClosed
+	return i.Status == StatusClosed || i.Status == StatusMerged || i.Status == StatusNotPlanned
+}
+
+// IsAbandoned checks if the issue was closed without being completed
+func (i *Issue) IsAbandoned() bool {
+	return i.Status == StatusNotPlanned
 }
 
-func (i *Issue) ToMap() map[int]*Issue {
-	issueMap := make(map[int]*Issue)
-	issueMap[i.ID] = i
+// IsDone checks if the issue is completed, closed pull requests
+// are not completed if only merged ones count
+func (i *Issue) IsDone(onlyMerged bool) bool {
+	if i.PullRequest && onlyMerged {
+		return i.Status == StatusMerged
+	}
 
-	for _, ci := range i.Children {
-		ciMap := ci.ToMap()
-		for k, v := range ciMap {
-			issueMap[k] = v
-		}
+	return i.IsClosed()
+}
+
+func (i *Issue) marker() string {
+	if i.Status == StatusLocked {
+		return "[locked] "
 	}
 
+	if !i.PullRequest {
+		return ""
+	}
+
+	switch i.Status {
+	case StatusDraft, StatusMerged, StatusClosed:
+		return fmt.Sprintf("[%v PR] ", i.Status)
+	default:
+		return "[PR] "
+	}
+}
+
+func (i *Issue) URL() string {
+	if len(i.HTMLURL) > 0 {
+		return i.HTMLURL
+	}
+
+	kind := "issues"
+	if i.PullRequest {
+		kind = "pull"
+	}
+
+	return fmt.Sprintf("https://github.com/%s/%s/%s/%v", i.Owner, i.Repo, kind, i.ID)
+}
+
+func (i *Issue) Ref() IssueRef {
+	return NewIssueRef(i.Owner, i.Repo, i.ID)
+}
+
+func (i *Issue) ToMap() map[IssueRef]*Issue {
+	issueMap := make(map[IssueRef]*Issue)
+	i.fillMap(issueMap)
 	return issueMap
 }
 
-func (i *Issue) FormatTitle(spaces int) string {
-	status := " "
-	if i.Status == StatusClosed {
-		status = "x"
+// Descendants returns unique descendants of the issue together with the issues that are
+// only listed in the sections, so the result does not depend on the issues fetched in this run,
+// listed issues are closed if they are shown as done
+func (i *Issue) Descendants() map[IssueRef]*Issue {
+	result := i.ToMap()
+
+	listed := make(map[IssueRef]bool)
+	for _, d := range result {
+		for r, done := range d.Listed {
+			if _, ok := result[r]; !ok {
+				listed[r] = listed[r] || done
+			}
+		}
+	}
+
+	delete(result, i.Ref())
+
+	for r, done := range listed {
+		li := &Issue{ID: r.Number, Owner: r.Owner, Repo: r.Repo, Status: StatusOpened, Unfetched: true}
+		if done {
+			li.Status = StatusClosed
+		}
+		result[r] = li
 	}
 
-	prefix := make([]rune, spaces)
-	for i := range prefix {
-		prefix[i] = ' '
+	return result
+}
+
+func (i *Issue) fillMap(issueMap map[IssueRef]*Issue) {
+	// same issue can be reachable from several parents
+	if _, ok := issueMap[i.Ref()]; ok {
+		return
 	}
 
-	return fmt.Sprintf("%s- [%s] %s #%v", string(prefix), status, i.Title, i.ID)
+	issueMap[i.Ref()] = i
+
+	for _, ci := range i.Children {
+		ci.fillMap(issueMap)
+	}
 }
 
-func NewIssue(i *github.Issue) *Issue {
+func NewIssue(i *github.Issue, owner, repo string) *Issue {
 	issue := &Issue{
-		ID:     i.GetNumber(),
-		Title:  i.GetTitle(),
-		Body:   i.GetBody(),
-		Status: StatusOpened,
+		ID:           i.GetNumber(),
+		Owner:        strings.ToLower(owner),
+		Repo:         strings.ToLower(repo),
+		DatabaseID:   i.GetID(),
+		Title:        i.GetTitle(),
+		Body:         i.GetBody(),
+		Status:       StatusOpened,
+		HTMLURL:      i.GetHTMLURL(),
+		Milestone:    i.GetMilestone().GetTitle(),
+		MilestoneDue: i.GetMilestone().GetDueOn().Time,
+		ClosedAt:     i.GetClosedAt().Time,
+		UpdatedAt:    i.GetUpdatedAt().Time,
+	}
+
+	for _, l := range i.Labels {
+		issue.Labels = append(issue.Labels, l.GetName())
+	}
+
+	for _, a := range i.Assignees {
+		issue.Assignees = append(issue.Assignees, a.GetLogin())
 	}
 
 	if i.GetLocked() {
 		issue.Status = StatusLocked
 	}
 
+	if i.IsPullRequest() {
+		issue.PullRequest = true
+
+		if i.GetDraft() {
+			issue.Status = StatusDraft
+		}
+	}
+
 	// status closed is more important than locked
 	if i.GetState() == "closed" {
 		issue.Status = StatusClosed
+
+		if i.GetStateReason() == "not_planned" {
+			issue.Status = StatusNotPlanned
+		}
+
+		if i.GetPullRequestLinks().GetMergedAt() != (github.Timestamp{}) {
+			issue.Status = StatusMerged
+		}
 	}
 
 	return issue
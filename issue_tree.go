@@ -3,62 +3,97 @@ package main
 import (
 	"bufio"
 	"errors"
+	"fmt"
 	"log"
-	"strconv"
+	"sort"
 	"strings"
 
 	"github.com/google/go-github/v73/github"
 )
 
 var (
-	errParentNotFound   = errors.New("parent issue not found")
-	errWrongIssueSyntax = errors.New("wrong issue syntax")
+	errParentNotFound = errors.New("parent issue not found")
 )
 
+// issueCycle is a loop in the issue hierarchy that was broken by ignoring a link
+type issueCycle struct {
+	Issues []IssueRef
+	Broken issueLink
+}
+
+func (c issueCycle) String() string {
+	refs := make([]string, 0, len(c.Issues)+1)
+	for _, r := range c.Issues {
+		refs = append(refs, r.String())
+	}
+	refs = append(refs, c.Issues[0].String())
+
+	return strings.Join(refs, " -> ")
+}
+
+// Comment explains the broken link to the child issue
+func (c issueCycle) Comment() string {
+	child := c.Broken.child
+	parent := c.Broken.parent.Relative(child.Owner, child.Repo)
+
+	if c.Broken.parent == child {
+		return fmt.Sprintf("This issue references itself as a parent. The link to %v was ignored, please fix the parent issue reference.", parent)
+	}
+
+	return fmt.Sprintf("This issue is a part of a cycle in the issue hierarchy: %v. The link to parent %v was ignored, please fix the parent issue reference.", c, parent)
+}
+
 type tree struct {
 	// map from parent to child issue
-	nodes   map[int]map[int]bool
-	issues  map[int]*Issue
-	missing []int
+	nodes  map[IssueRef]map[IssueRef]bool
+	issues map[IssueRef]*Issue
+	cycles []issueCycle
+	// parse "Parent: #N" lines of added issues
+	textLinks bool
+	// missing issues that were already requested
+	requested map[IssueRef]bool
 }
 
-func isParentIssueMark(m string) bool {
-	if len(m) == 0 {
-		return false
+func sortedRefs(refs map[IssueRef]bool) []IssueRef {
+	sorted := make([]IssueRef, 0, len(refs))
+	for r := range refs {
+		sorted = append(sorted, r)
 	}
 
-	m = strings.ToLower(strings.TrimSpace(m))
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Less(sorted[j]) })
 
-	return m == "parent issue" ||
-		m == "epic" ||
-		m == "parent"
+	return sorted
 }
 
-func parseIssueNumber(s string) (int, error) {
-	s = strings.TrimSpace(s)
+// marks of the "Parent: #N" line in the child issue body
+var parentIssueMarks = []string{"Parent", "Epic", "Parent issue"}
 
-	// 10 digits of max-int + '#'
-	if s[0] != '#' || len(s) > 11 {
-		return -1, errWrongIssueSyntax
+func isParentIssueMark(m string) bool {
+	m = strings.TrimSpace(m)
+
+	for _, mark := range parentIssueMarks {
+		if strings.EqualFold(m, mark) {
+			return true
+		}
 	}
 
-	return strconv.Atoi(s[1:])
+	return false
 }
 
-func parseParentIssue(i *github.Issue) (int, error) {
-	scanner := bufio.NewScanner(strings.NewReader(i.GetBody()))
+func parseParentIssues(i *Issue) ([]IssueRef, error) {
+	parents := make([]IssueRef, 0)
+	seen := make(map[IssueRef]bool)
+
+	scanner := bufio.NewScanner(strings.NewReader(i.Body))
 	for scanner.Scan() {
 		line := scanner.Text()
 
-		if !strings.Contains(line, "#") {
-			continue
-		}
-
 		if !strings.Contains(line, ":") {
 			continue
 		}
 
-		parts := strings.Split(line, ":")
+		// parent issue can be a URL that contains a colon too
+		parts := strings.SplitN(line, ":", 2)
 		if len(parts) != 2 {
 			continue
 		}
@@ -67,81 +102,235 @@ func parseParentIssue(i *github.Issue) (int, error) {
 			continue
 		}
 
-		issue, err := parseIssueNumber(parts[1])
+		issue, err := parseIssueRef(parts[1], i.Owner, i.Repo)
 		if err != nil {
 			log.Printf("Failed to parse parent issue. line=%v err=%v", line, err)
 			continue
 		}
 
-		return issue, nil
+		if seen[issue] {
+			continue
+		}
+
+		seen[issue] = true
+		parents = append(parents, issue)
+	}
+
+	if len(parents) == 0 {
+		return nil, errParentNotFound
 	}
 
-	return -1, errParentNotFound
+	return parents, nil
 }
 
-func NewTree(issues []*github.Issue) *tree {
+// NewTree creates a tree from the issues of owner/repo,
+// textLinks enables parsing of the "Parent: #N" lines in the issue body
+func NewTree(issues []*github.Issue, owner, repo string, textLinks bool) *tree {
 	t := &tree{
-		nodes:   make(map[int]map[int]bool),
-		issues:  make(map[int]*Issue),
-		missing: make([]int, 0),
+		nodes:     make(map[IssueRef]map[IssueRef]bool),
+		issues:    make(map[IssueRef]*Issue),
+		textLinks: textLinks,
+		requested: make(map[IssueRef]bool),
 	}
 
-	for _, i := range issues {
-		child := i.GetNumber()
-		t.issues[child] = NewIssue(i)
+	for _, gi := range issues {
+		i := NewIssue(gi, owner, repo)
+		t.issues[i.Ref()] = i
+		t.addTextLinks(i)
+	}
 
-		parent, err := parseParentIssue(i)
-		if err != nil {
-			log.Printf("Failed to parse parent issue. issue=%v err=%v", i.GetNumber(), err)
-			continue
-		}
+	t.breakCycles()
+
+	return t
+}
 
+func (t *tree) addTextLinks(i *Issue) {
+	if !t.textLinks {
+		return
+	}
+
+	child := i.Ref()
+	parents, err := parseParentIssues(i)
+	if err != nil {
+		log.Printf("Failed to parse parent issue. issue=%v err=%v", child, err)
+		return
+	}
+
+	for _, parent := range parents {
 		t.addNode(parent, child)
 	}
+}
+
+// Missing returns parent issues that were neither fetched nor requested yet
+func (t *tree) Missing() []IssueRef {
+	missing := make([]IssueRef, 0)
 
 	for p, _ := range t.nodes {
-		if _, ok := t.issues[p]; !ok {
-			t.missing = append(t.missing, p)
+		if _, ok := t.issues[p]; ok {
+			continue
+		}
+
+		if t.requested[p] {
+			continue
 		}
+
+		t.requested[p] = true
+		missing = append(missing, p)
 	}
 
-	log.Printf("Processed missing parent issues. count=%v", len(t.missing))
+	log.Printf("Processed missing parent issues. count=%v", len(missing))
 
-	return t
+	return missing
 }
 
-func (t *tree) addNode(parent, child int) {
+// AddLink adds a link between issues that are not necessarily fetched yet
+func (t *tree) AddLink(parent, child *Issue) {
+	for _, i := range []*Issue{parent, child} {
+		if _, ok := t.issues[i.Ref()]; !ok {
+			t.issues[i.Ref()] = i
+		}
+	}
+
+	t.addNode(parent.Ref(), child.Ref())
+}
+
+func (t *tree) addNode(parent, child IssueRef) {
+	if parent == child {
+		log.Printf("Issue cannot be its own parent. issue=%v", child)
+		t.cycles = append(t.cycles, issueCycle{
+			Issues: []IssueRef{child},
+			Broken: issueLink{parent, child},
+		})
+		return
+	}
+
 	if _, ok := t.nodes[parent]; !ok {
-		t.nodes[parent] = make(map[int]bool)
+		t.nodes[parent] = make(map[IssueRef]bool)
 	}
 
 	t.nodes[parent][child] = true
 	log.Printf("Added issues link. parent=%v child=%v", parent, child)
 }
 
-func (t *tree) AddParentIssues(issues []*github.Issue) {
-	log.Printf("Adding additional parent issues. count=%v", len(issues))
+// parents returns sorted references of all parent issues
+func (t *tree) parents() []IssueRef {
+	parents := make(map[IssueRef]bool)
+	for p := range t.nodes {
+		parents[p] = true
+	}
+
+	return sortedRefs(parents)
+}
+
+func (t *tree) removeNode(parent, child IssueRef) {
+	delete(t.nodes[parent], child)
+	if len(t.nodes[parent]) == 0 {
+		delete(t.nodes, parent)
+	}
+}
+
+// breakCycles ignores links that close a loop in the hierarchy,
+// issues are visited in a sorted order so the same link is ignored every run
+func (t *tree) breakCycles() {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	state := make(map[IssueRef]int)
+	path := make([]IssueRef, 0)
+
+	var visit func(r IssueRef)
+	visit = func(r IssueRef) {
+		state[r] = visiting
+		path = append(path, r)
+
+		for _, c := range sortedRefs(t.nodes[r]) {
+			switch state[c] {
+			case unvisited:
+				visit(c)
+			case visiting:
+				start := len(path) - 1
+				for path[start] != c {
+					start--
+				}
+
+				cycle := issueCycle{
+					Issues: append([]IssueRef{}, path[start:]...),
+					Broken: issueLink{r, c},
+				}
+				log.Printf("Found a cycle in issues. cycle=%v parent=%v child=%v", cycle, r, c)
+				t.cycles = append(t.cycles, cycle)
+				t.removeNode(r, c)
+			}
+		}
+
+		path = path[:len(path)-1]
+		state[r] = visited
+	}
+
+	for _, p := range t.parents() {
+		if state[p] == unvisited {
+			visit(p)
+		}
+	}
+}
+
+// AddChildren adds issues that reference the parent, other issues are ignored
+func (t *tree) AddChildren(parent IssueRef, issues []*Issue) {
+	log.Printf("Adding child issues. parent=%v count=%v", parent, len(issues))
 	for _, i := range issues {
-		issue := NewIssue(i)
+		parents, err := parseParentIssues(i)
+		if err != nil {
+			continue
+		}
+
+		for _, p := range parents {
+			if p != parent {
+				continue
+			}
+
+			if _, ok := t.issues[i.Ref()]; !ok {
+				t.issues[i.Ref()] = i
+			}
+
+			t.addNode(parent, i.Ref())
+		}
+	}
+}
+
+// AddParentIssues adds fetched parents together with their own parent links
+// so the next call to Missing() returns the ancestors one level up
+func (t *tree) AddParentIssues(issues []*Issue) {
+	log.Printf("Adding additional parent issues. count=%v", len(issues))
+	for _, issue := range issues {
+		ref := issue.Ref()
 
-		if _, ok := t.issues[issue.ID]; ok {
-			log.Printf("Parent issue is already added. issue=%v", issue.ID)
+		if _, ok := t.issues[ref]; ok {
+			log.Printf("Parent issue is already added. issue=%v", ref)
 			continue
 		}
 
-		t.issues[issue.ID] = issue
+		t.issues[ref] = issue
+		t.addTextLinks(issue)
 	}
 }
 
 func (t *tree) Issues() []*Issue {
+	// links could have been added after the tree was created
+	t.breakCycles()
+
 	log.Printf("Making a list out of issue tree. nodes_count=%v", len(t.nodes))
 	issues := make([]*Issue, 0, len(t.nodes))
 
-	for p, cm := range t.nodes {
+	// sorted order keeps the result the same between runs
+	for _, p := range t.parents() {
+		cm := t.nodes[p]
 		log.Printf("Generating children list. parent=%v children_count=%v", p, len(cm))
 		children := make([]*Issue, 0, len(cm))
 
-		for i, _ := range cm {
+		for _, i := range sortedRefs(cm) {
 			if _, ok := t.issues[i]; !ok {
 				log.Printf("Child issue is not found. issue=%v", i)
 				continue
@@ -165,3 +354,92 @@ func (t *tree) Issues() []*Issue {
 
 	return issues
 }
+
+// FormerParents returns issues with a child section that have no children in the tree
+func (t *tree) FormerParents(hasSection func(body string) bool) []*Issue {
+	issues := make([]*Issue, 0)
+
+	for r, i := range t.issues {
+		if _, ok := t.nodes[r]; ok {
+			continue
+		}
+
+		if hasSection(i.Body) {
+			issues = append(issues, i)
+		}
+	}
+
+	log.Printf("Found former parent issues. count=%v", len(issues))
+
+	return issues
+}
+
+// parentOf returns the first parent of the issue in the sorted order
+func (t *tree) parentOf(child IssueRef) (IssueRef, bool) {
+	for _, p := range t.parents() {
+		if t.nodes[p][child] {
+			return p, true
+		}
+	}
+
+	return IssueRef{}, false
+}
+
+// Ancestors returns the chain of parents from the root to the direct parent,
+// the first parent is followed if the issue has several
+func (t *tree) Ancestors(i *Issue) []*Issue {
+	chain := make([]*Issue, 0)
+	seen := map[IssueRef]bool{i.Ref(): true}
+
+	for r := i.Ref(); ; {
+		p, ok := t.parentOf(r)
+		if !ok || seen[p] {
+			break
+		}
+
+		pi, ok := t.issues[p]
+		if !ok {
+			break
+		}
+
+		seen[p] = true
+		chain = append([]*Issue{pi}, chain...)
+		r = p
+	}
+
+	return chain
+}
+
+// ChildIssues returns sorted issues that have a parent or satisfy the predicate
+func (t *tree) ChildIssues(keep func(body string) bool) []*Issue {
+	refs := make(map[IssueRef]bool)
+	for _, children := range t.nodes {
+		for c := range children {
+			refs[c] = true
+		}
+	}
+
+	for r, i := range t.issues {
+		if keep(i.Body) {
+			refs[r] = true
+		}
+	}
+
+	issues := make([]*Issue, 0, len(refs))
+	for _, r := range sortedRefs(refs) {
+		if i, ok := t.issues[r]; ok {
+			issues = append(issues, i)
+		}
+	}
+
+	log.Printf("Found child issues. count=%v", len(issues))
+
+	return issues
+}
+
+// SetListed parses children listed in the sections of all issues in the tree
+func (t *tree) SetListed(listed func(i *Issue, body string) map[IssueRef]bool) {
+	for _, i := range t.issues {
+		i.Listed = listed(i, i.Body)
+	}
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v73/github"
+)
+
+func TestParseParentIssues(t *testing.T) {
+	tests := []struct {
+		body     string
+		expected []IssueRef
+	}{
+		{"Parent: #12", []IssueRef{{"owner", "repo", 12}}},
+		{"abcd\nEpic: #3\nefgh", []IssueRef{{"owner", "repo", 3}}},
+		{"Parent issue: Org/Planning#123", []IssueRef{{"org", "planning", 123}}},
+		{"Parent: https://github.com/org/planning/issues/45", []IssueRef{{"org", "planning", 45}}},
+		{"Epic: #3\nParent: #7\nParent: #3", []IssueRef{{"owner", "repo", 3}, {"owner", "repo", 7}}},
+	}
+
+	for _, tt := range tests {
+		i := &Issue{ID: 1, Owner: "owner", Repo: "repo", Body: tt.body}
+		refs, err := parseParentIssues(i)
+		if err != nil {
+			t.Errorf("Failed to parse parent. body=%v err=%v", tt.body, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(refs, tt.expected) {
+			t.Errorf("Parents do not match. actual=%v expected=%v", refs, tt.expected)
+		}
+	}
+}
+
+func TestParseParentIssuesWrongSyntax(t *testing.T) {
+	bodies := []string{
+		"Parent: 12",
+		"Parent: #12 and #13",
+		"Parent: https://example.com/org/planning/issues/45",
+		"Something: #12",
+	}
+
+	for _, b := range bodies {
+		i := &Issue{ID: 1, Owner: "owner", Repo: "repo", Body: b}
+		if refs, err := parseParentIssues(i); err == nil {
+			t.Errorf("Parsed wrong parent. body=%v refs=%v", b, refs)
+		}
+	}
+}
+
+func newGithubIssue(number int, body string) *github.Issue {
+	return &github.Issue{
+		Number: github.Ptr(number),
+		Title:  github.Ptr("Issue"),
+		Body:   github.Ptr(body),
+		State:  github.Ptr("open"),
+	}
+}
+
+func TestTreeMultipleParents(t *testing.T) {
+	tr := NewTree([]*github.Issue{
+		newGithubIssue(1, ""),
+		newGithubIssue(2, ""),
+		newGithubIssue(3, "Parent: #1\nParent: #2"),
+	}, "owner", "repo", true /*text links*/)
+
+	issues := tr.Issues()
+	if len(issues) != 2 {
+		t.Fatalf("Parents count does not match. actual=%v expected=%v", len(issues), 2)
+	}
+
+	for _, i := range issues {
+		if len(i.Children) != 1 || i.Children[0].ID != 3 {
+			t.Errorf("Shared child is missing. parent=%v children=%v", i.ID, i.Children)
+		}
+	}
+}
+
+func TestTreeBreakCycle(t *testing.T) {
+	tr := NewTree([]*github.Issue{
+		newGithubIssue(1, "Parent: #2"),
+		newGithubIssue(2, "Parent: #1"),
+		newGithubIssue(3, "Parent: #2"),
+	}, "owner", "repo", true /*text links*/)
+
+	if len(tr.cycles) != 1 {
+		t.Fatalf("Cycles count does not match. actual=%v expected=%v", len(tr.cycles), 1)
+	}
+
+	broken := issueLink{NewIssueRef("owner", "repo", 2), NewIssueRef("owner", "repo", 1)}
+	if tr.cycles[0].Broken != broken {
+		t.Errorf("Broken link does not match. actual=%v expected=%v", tr.cycles[0].Broken, broken)
+	}
+
+	if s := tr.cycles[0].String(); s != "owner/repo#1 -> owner/repo#2 -> owner/repo#1" {
+		t.Errorf("Cycle does not match. actual=%v", s)
+	}
+
+	for _, i := range tr.Issues() {
+		if i.ID != 1 {
+			continue
+		}
+
+		if len(i.ToMap()) != 3 {
+			t.Errorf("Hierarchy does not match. actual=%v expected=%v", len(i.ToMap()), 3)
+		}
+		return
+	}
+
+	t.Errorf("Root issue is not found")
+}
+
+func TestTreeBreakLongCycle(t *testing.T) {
+	tr := NewTree([]*github.Issue{
+		newGithubIssue(1, "Parent: #3"),
+		newGithubIssue(2, "Parent: #1"),
+		newGithubIssue(3, "Parent: #2"),
+	}, "owner", "repo", true /*text links*/)
+
+	if len(tr.cycles) != 1 || len(tr.cycles[0].Issues) != 3 {
+		t.Fatalf("Cycles do not match. cycles=%v", tr.cycles)
+	}
+
+	// breaking cycles twice should not find anything new
+	tr.Issues()
+	if len(tr.cycles) != 1 {
+		t.Errorf("Cycles count does not match. actual=%v expected=%v", len(tr.cycles), 1)
+	}
+}
+
+func TestTreeSelfParent(t *testing.T) {
+	tr := NewTree([]*github.Issue{
+		newGithubIssue(1, "Parent: #1"),
+	}, "owner", "repo", true /*text links*/)
+
+	if len(tr.cycles) != 1 {
+		t.Fatalf("Cycles count does not match. actual=%v expected=%v", len(tr.cycles), 1)
+	}
+
+	if issues := tr.Issues(); len(issues) != 0 {
+		t.Errorf("Self parent issue was added. issues=%v", issues)
+	}
+}
+
+func TestTreeMissingAncestors(t *testing.T) {
+	tr := NewTree([]*github.Issue{
+		newGithubIssue(3, "Parent: #2"),
+	}, "owner", "repo", true /*text links*/)
+
+	missing := tr.Missing()
+	if len(missing) != 1 || missing[0].Number != 2 {
+		t.Fatalf("Missing issues do not match. actual=%v", missing)
+	}
+	tr.AddParentIssues([]*Issue{NewIssue(newGithubIssue(2, "Parent: #1"), "owner", "repo")})
+
+	missing = tr.Missing()
+	if len(missing) != 1 || missing[0].Number != 1 {
+		t.Fatalf("Missing issues do not match. actual=%v", missing)
+	}
+	// pretend that the root could not be fetched
+	tr.AddParentIssues(nil)
+
+	if missing = tr.Missing(); len(missing) != 0 {
+		t.Fatalf("Failed issue was requested again. missing=%v", missing)
+	}
+
+	issues := tr.Issues()
+	if len(issues) != 1 || issues[0].ID != 2 {
+		t.Fatalf("Parent issues do not match. issues=%v", issues)
+	}
+
+	if len(issues[0].Children) != 1 || issues[0].Children[0].ID != 3 {
+		t.Errorf("Children do not match. children=%v", issues[0].Children)
+	}
+}
+
+func TestTreeAncestorsHierarchy(t *testing.T) {
+	tr := NewTree([]*github.Issue{
+		newGithubIssue(3, "Parent: #2"),
+	}, "owner", "repo", true /*text links*/)
+
+	tr.Missing()
+	tr.AddParentIssues([]*Issue{NewIssue(newGithubIssue(2, "Parent: #1"), "owner", "repo")})
+	tr.Missing()
+	tr.AddParentIssues([]*Issue{NewIssue(newGithubIssue(1, ""), "owner", "repo")})
+
+	for _, i := range tr.Issues() {
+		if i.ID != 1 {
+			continue
+		}
+
+		if len(i.ToMap()) != 3 {
+			t.Errorf("Hierarchy does not match. actual=%v expected=%v", len(i.ToMap()), 3)
+		}
+		return
+	}
+
+	t.Errorf("Root issue is not found")
+}
+
+func TestNewIssueStatus(t *testing.T) {
+	merged := github.Timestamp{Time: time.Now()}
+	tests := []struct {
+		issue       *github.Issue
+		status      IssueStatus
+		pullRequest bool
+	}{
+		{&github.Issue{State: github.Ptr("open")}, StatusOpened, false},
+		{&github.Issue{State: github.Ptr("closed")}, StatusClosed, false},
+		{&github.Issue{State: github.Ptr("closed"), StateReason: github.Ptr("completed")}, StatusClosed, false},
+		{&github.Issue{State: github.Ptr("closed"), StateReason: github.Ptr("not_planned")}, StatusNotPlanned, false},
+		{&github.Issue{State: github.Ptr("open"), Locked: github.Ptr(true)}, StatusLocked, false},
+		{&github.Issue{State: github.Ptr("open"), PullRequestLinks: &github.PullRequestLinks{}}, StatusOpened, true},
+		{&github.Issue{State: github.Ptr("open"), Draft: github.Ptr(true), PullRequestLinks: &github.PullRequestLinks{}}, StatusDraft, true},
+		{&github.Issue{State: github.Ptr("closed"), PullRequestLinks: &github.PullRequestLinks{}}, StatusClosed, true},
+		{&github.Issue{State: github.Ptr("closed"), PullRequestLinks: &github.PullRequestLinks{MergedAt: &merged}}, StatusMerged, true},
+	}
+
+	for _, tt := range tests {
+		i := NewIssue(tt.issue, "owner", "repo")
+		if i.Status != tt.status || i.PullRequest != tt.pullRequest {
+			t.Errorf("Status does not match. actual=%v expected=%v pr=%v", i.Status, tt.status, i.PullRequest)
+		}
+	}
+}
+
+func TestTreeAncestors(t *testing.T) {
+	tr := NewTree([]*github.Issue{
+		newGithubIssue(1, ""),
+		newGithubIssue(2, ""),
+		newGithubIssue(3, "Parent: #2\nParent: #1"),
+		newGithubIssue(4, "Parent: #3"),
+		newGithubIssue(5, "Something"),
+	}, "owner", "repo", true /*text links*/)
+	tr.Issues()
+
+	tests := []struct {
+		id       int
+		expected []int
+	}{
+		{4, []int{1, 3}},
+		{3, []int{1}},
+		{1, []int{}},
+	}
+
+	for _, tt := range tests {
+		ancestors := tr.Ancestors(tr.issues[NewIssueRef("owner", "repo", tt.id)])
+		ids := make([]int, 0, len(ancestors))
+		for _, a := range ancestors {
+			ids = append(ids, a.ID)
+		}
+
+		if !reflect.DeepEqual(ids, tt.expected) {
+			t.Errorf("Ancestors do not match. issue=%v actual=%v expected=%v", tt.id, ids, tt.expected)
+		}
+	}
+
+	children := tr.ChildIssues(hasBreadcrumb)
+	if len(children) != 2 || children[0].ID != 3 || children[1].ID != 4 {
+		t.Errorf("Child issues do not match. actual=%v", children)
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+const (
+	statusNotStarted     = "status/not-started"
+	statusInProgress     = "status/in-progress"
+	statusDone           = "status/done"
+	statusBlocked        = "status/blocked"
+	defaultBlockingLabel = "blocked"
+)
+
+// StatusLabeler keeps the status label of the parent derived from its descendants
+type StatusLabeler struct {
+	BlockingLabels []string
+	OnlyMergedDone bool
+}
+
+func hasLabel(i *Issue, labels []string) bool {
+	for _, l := range i.Labels {
+		for _, bl := range labels {
+			if strings.EqualFold(l, bl) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Status returns the status label or an empty string for issues without children
+func (l *StatusLabeler) Status(i *Issue) string {
+	total, done, blocked := 0, 0, false
+
+	// children listed in the section count even if they were not fetched
+	for _, ci := range i.Descendants() {
+		total++
+		if ci.IsDone(l.OnlyMergedDone) || ci.IsAbandoned() {
+			done++
+		} else if hasLabel(ci, l.BlockingLabels) {
+			blocked = true
+		}
+	}
+
+	switch {
+	case total == 0:
+		return ""
+	case done == total:
+		return statusDone
+	case blocked:
+		return statusBlocked
+	case done == 0:
+		return statusNotStarted
+	default:
+		return statusInProgress
+	}
+}
+
+// LabelChange adds the new status label and removes stale status labels
+type LabelChange struct {
+	Add    string
+	Remove []string
+}
+
+func (c *LabelChange) String() string {
+	return fmt.Sprintf("Changed status label to %v", c.Add)
+}
+
+// Change returns the change of status labels of the issue, nil is returned if labels do not change,
+// labels other than the managed status labels are kept
+func (l *StatusLabeler) Change(i *Issue) *LabelChange {
+	status := l.Status(i)
+	if len(status) == 0 {
+		return nil
+	}
+
+	managed := []string{statusNotStarted, statusInProgress, statusDone, statusBlocked}
+	change := &LabelChange{Add: status}
+	present := false
+
+	for _, label := range i.Labels {
+		if strings.EqualFold(label, status) {
+			present = true
+			continue
+		}
+
+		for _, m := range managed {
+			if strings.EqualFold(label, m) {
+				change.Remove = append(change.Remove, label)
+			}
+		}
+	}
+
+	if present && len(change.Remove) == 0 {
+		return nil
+	}
+
+	return change
+}
+
+// updateLabels adds the new status label of the issue and removes stale ones
+func (s *service) updateLabels(i *Issue, change *LabelChange, changelog []string) {
+	defer s.wg.Done()
+
+	ref := i.Ref()
+	log.Printf("About to update issue labels. issue=%v add=%v remove=%v", ref, change.Add, change.Remove)
+	if s.env.dryRun {
+		log.Printf("Dry run mode.")
+		return
+	}
+
+	// labels are changed one by one so labels added meanwhile are kept
+	_, _, err := s.client.Issues.AddLabelsToIssue(s.ctx, ref.Owner, ref.Repo, ref.Number, []string{change.Add})
+	if err != nil {
+		log.Printf("Error while adding a label. issue=%v label=%v err=%v", ref, change.Add, err)
+		return
+	}
+
+	for _, label := range change.Remove {
+		_, err = s.client.Issues.RemoveLabelForIssue(s.ctx, ref.Owner, ref.Repo, ref.Number, label)
+		if err != nil {
+			log.Printf("Error while removing a label. issue=%v label=%v err=%v", ref, label, err)
+			return
+		}
+	}
+
+	log.Printf("Updated issue labels. issue=%v", ref)
+
+	s.addChangelog(ref, changelog)
+}
+
+// changeLabels starts the update of labels, the changelog entry goes with the body update
+// if the body is changed too
+func (s *service) changeLabels(i *Issue, labels *LabelChange, bodyChanged bool, changeLog []string) []string {
+	if labels == nil {
+		return changeLog
+	}
+
+	var labelChangeLog []string
+	if bodyChanged {
+		changeLog = append(changeLog, labels.String())
+	} else {
+		labelChangeLog = []string{labels.String()}
+	}
+
+	s.wg.Add(1)
+	go s.updateLabels(i, labels, labelChangeLog)
+
+	return changeLog
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestStatusLabel(t *testing.T) {
+	tests := []struct {
+		children []*Issue
+		expected string
+	}{
+		{nil, ""},
+		{[]*Issue{&Issue{ID: 2}, &Issue{ID: 3}}, statusNotStarted},
+		{[]*Issue{&Issue{ID: 2, Status: StatusClosed}, &Issue{ID: 3}}, statusInProgress},
+		{[]*Issue{&Issue{ID: 2, Status: StatusClosed}, &Issue{ID: 3, Status: StatusNotPlanned}}, statusDone},
+		{[]*Issue{&Issue{ID: 2, Status: StatusClosed}, &Issue{ID: 3, Children: []*Issue{
+			&Issue{ID: 4, Labels: []string{"Blocked"}}}}}, statusBlocked},
+		{[]*Issue{&Issue{ID: 2, Status: StatusClosed, Labels: []string{"blocked"}}}, statusDone},
+	}
+
+	l := &StatusLabeler{BlockingLabels: []string{"blocked"}}
+	for _, tt := range tests {
+		i := &Issue{ID: 1, Children: tt.children}
+		if status := l.Status(i); status != tt.expected {
+			t.Errorf("Status does not match. children=%v actual=%v expected=%v", tt.children, status, tt.expected)
+		}
+	}
+}
+
+func TestStatusLabelUnfetched(t *testing.T) {
+	body := `### Child issues:
+
+- [ ] Old child #10
+- [ ] New child #11
+`
+	// only the child updated in this run is fetched
+	i := &Issue{ID: 1, Body: body, Children: []*Issue{&Issue{ID: 11, Status: StatusClosed}}}
+	i.Listed = (&Editor{}).ListedChildren(i, i.Body)
+
+	l := &StatusLabeler{}
+	if status := l.Status(i); status != statusInProgress {
+		t.Errorf("Status does not match. actual=%v expected=%v", status, statusInProgress)
+	}
+
+	i.Listed[NewIssueRef("", "", 10)] = true
+	if status := l.Status(i); status != statusDone {
+		t.Errorf("Status does not match. actual=%v expected=%v", status, statusDone)
+	}
+}
+
+func TestStatusLabelChange(t *testing.T) {
+	children := []*Issue{&Issue{ID: 2, Status: StatusClosed}, &Issue{ID: 3}}
+
+	tests := []struct {
+		labels   []string
+		expected *LabelChange
+	}{
+		{[]string{"epic"}, &LabelChange{Add: statusInProgress}},
+		{[]string{statusNotStarted, "epic"}, &LabelChange{Add: statusInProgress, Remove: []string{statusNotStarted}}},
+		{[]string{"epic", statusInProgress}, nil},
+		{[]string{"Status/In-Progress"}, nil},
+		{[]string{statusInProgress, statusDone}, &LabelChange{Add: statusInProgress, Remove: []string{statusDone}}},
+		{[]string{"status/needs-info", statusDone, "status/wontfix"}, &LabelChange{Add: statusInProgress, Remove: []string{statusDone}}},
+		{[]string{"status/needs-info", statusInProgress}, nil},
+	}
+
+	l := &StatusLabeler{}
+	for _, tt := range tests {
+		i := &Issue{ID: 1, Labels: tt.labels, Children: children}
+		if change := l.Change(i); !reflect.DeepEqual(change, tt.expected) {
+			t.Errorf("Label change does not match. labels=%v actual=%v expected=%v", tt.labels, change, tt.expected)
+		}
+	}
+}
+
+func TestUpdateLabels(t *testing.T) {
+	var added []string
+	removed := make([]string, 0)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /repos/owner/repo/issues/1/labels", func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&added)
+		w.Write([]byte(`[]`))
+	})
+	mux.HandleFunc("DELETE /repos/owner/repo/issues/1/labels/{label...}", func(w http.ResponseWriter, r *http.Request) {
+		removed = append(removed, r.PathValue("label"))
+	})
+	mux.HandleFunc("PATCH /repos/owner/repo/issues/1", func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("Issue is edited instead of label update")
+	})
+
+	svc := newTestService(t, mux)
+	i := &Issue{ID: 1, Owner: "owner", Repo: "repo", Labels: []string{"status/needs-info", statusNotStarted}}
+
+	svc.wg.Add(1)
+	svc.updateLabels(i, &LabelChange{Add: statusInProgress, Remove: []string{statusNotStarted}}, nil)
+
+	if fmt.Sprint(added) != fmt.Sprint([]string{statusInProgress}) || fmt.Sprint(removed) != fmt.Sprint([]string{statusNotStarted}) {
+		t.Errorf("Labels do not match. added=%v removed=%v", added, removed)
+	}
+}
@@ -21,14 +21,46 @@ const (
 )
 
 type env struct {
-	token        string
-	owner        string
-	repo         string
-	syncDays     int
-	maxLevels    int
-	addChangelog bool
-	dryRun       bool
-	updateClosed bool
+	token          string
+	owner          string
+	repo           string
+	syncDays       int
+	maxLevels      int
+	addChangelog   bool
+	dryRun         bool
+	updateClosed   bool
+	linkSource     string
+	syncSubIssues  bool
+	commentCycles  bool
+	prune          PruneMode
+	discover       bool
+	pullRequests   bool
+	onlyMerged     bool
+	notPlanned     NotPlannedStyle
+	showProgress   bool
+	progressBar    bool
+	lineTemplate   string
+	sortBy         SortPolicy
+	priorities     []string
+	resort         bool
+	groupBy        GroupPolicy
+	groupPrefix    string
+	render         RenderMode
+	showDiagram    bool
+	collapse       bool
+	collapseAfter  int
+	markers        bool
+	heading        string
+	position       SectionPosition
+	commentMode    bool
+	autoClose      bool
+	autoReopen     bool
+	statusLabels   bool
+	blockingLabels []string
+	showEstimates  bool
+	estimateLabels string
+	weighted       bool
+	breadcrumbs    bool
 }
 
 type service struct {
@@ -43,16 +75,95 @@ func flagToBool(s string) bool {
 	return s == "1" || s == "true" || s == "y" || s == "yes"
 }
 
+func flagToBoolDefault(s string, def bool) bool {
+	if len(strings.TrimSpace(s)) == 0 {
+		return def
+	}
+
+	return flagToBool(s)
+}
+
+func splitList(s string) []string {
+	result := make([]string, 0)
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); len(item) > 0 {
+			result = append(result, item)
+		}
+	}
+
+	return result
+}
+
+func parseNotPlannedStyle(s string) NotPlannedStyle {
+	if strings.ToLower(strings.TrimSpace(s)) == "check" {
+		return NotPlannedCheck
+	}
+
+	return NotPlannedStrike
+}
+
 func environment() *env {
 	r := strings.Split(os.Getenv("INPUT_REPO"), "/")
 
 	e := &env{
-		owner:        r[0],
-		repo:         r[1],
-		token:        os.Getenv("INPUT_TOKEN"),
-		dryRun:       flagToBool(os.Getenv("INPUT_DRY_RUN")),
-		addChangelog: flagToBool(os.Getenv("INPUT_ADD_CHANGELOG")),
-		updateClosed: flagToBool(os.Getenv("INPUT_UPDATE_CLOSED")),
+		owner:          r[0],
+		repo:           r[1],
+		token:          os.Getenv("INPUT_TOKEN"),
+		dryRun:         flagToBool(os.Getenv("INPUT_DRY_RUN")),
+		addChangelog:   flagToBool(os.Getenv("INPUT_ADD_CHANGELOG")),
+		updateClosed:   flagToBool(os.Getenv("INPUT_UPDATE_CLOSED")),
+		linkSource:     parseLinkSource(os.Getenv("INPUT_LINK_SOURCE")),
+		syncSubIssues:  flagToBool(os.Getenv("INPUT_SYNC_SUB_ISSUES")),
+		commentCycles:  flagToBool(os.Getenv("INPUT_COMMENT_CYCLES")),
+		prune:          parsePruneMode(os.Getenv("INPUT_PRUNE_CHILDREN")),
+		discover:       flagToBool(os.Getenv("INPUT_DISCOVER_CHILDREN")),
+		pullRequests:   flagToBoolDefault(os.Getenv("INPUT_INCLUDE_PULL_REQUESTS"), true),
+		onlyMerged:     flagToBool(os.Getenv("INPUT_ONLY_MERGED_DONE")),
+		notPlanned:     parseNotPlannedStyle(os.Getenv("INPUT_NOT_PLANNED_STYLE")),
+		showProgress:   flagToBool(os.Getenv("INPUT_SHOW_PROGRESS")),
+		progressBar:    flagToBool(os.Getenv("INPUT_PROGRESS_BAR")),
+		lineTemplate:   os.Getenv("INPUT_LINE_TEMPLATE"),
+		sortBy:         parseSortPolicy(os.Getenv("INPUT_SORT_BY")),
+		priorities:     splitList(os.Getenv("INPUT_PRIORITY_LABELS")),
+		resort:         flagToBool(os.Getenv("INPUT_RESORT")),
+		render:         parseRenderMode(os.Getenv("INPUT_RENDER_MODE")),
+		showDiagram:    flagToBool(os.Getenv("INPUT_SHOW_DIAGRAM")),
+		collapse:       flagToBool(os.Getenv("INPUT_COLLAPSE_NESTED")),
+		markers:        flagToBoolDefault(os.Getenv("INPUT_SECTION_MARKERS"), true),
+		heading:        strings.TrimSpace(os.Getenv("INPUT_SECTION_HEADING")),
+		position:       parseSectionPosition(os.Getenv("INPUT_SECTION_POSITION")),
+		commentMode:    flagToBool(os.Getenv("INPUT_COMMENT_MODE")),
+		autoClose:      flagToBool(os.Getenv("INPUT_AUTO_CLOSE")),
+		autoReopen:     flagToBool(os.Getenv("INPUT_AUTO_REOPEN")),
+		statusLabels:   flagToBool(os.Getenv("INPUT_STATUS_LABELS")),
+		blockingLabels: splitList(os.Getenv("INPUT_BLOCKING_LABELS")),
+		showEstimates:  flagToBool(os.Getenv("INPUT_SHOW_ESTIMATES")),
+		estimateLabels: os.Getenv("INPUT_ESTIMATE_LABELS"),
+		weighted:       flagToBool(os.Getenv("INPUT_WEIGHTED_PROGRESS")),
+		breadcrumbs:    flagToBool(os.Getenv("INPUT_BREADCRUMBS")),
+	}
+
+	e.groupBy, e.groupPrefix = parseGroupPolicy(os.Getenv("INPUT_GROUP_BY"))
+
+	if e.position == SectionTop && !e.markers {
+		log.Printf("Section markers are required for the section on top. position=top")
+		e.markers = true
+	}
+
+	if len(e.blockingLabels) == 0 {
+		e.blockingLabels = []string{defaultBlockingLabel}
+	}
+
+	if len(strings.TrimSpace(e.estimateLabels)) == 0 {
+		e.estimateLabels = defaultEstimateLabels
+	}
+
+	if templateFile := os.Getenv("INPUT_LINE_TEMPLATE_FILE"); len(templateFile) > 0 {
+		data, err := os.ReadFile(templateFile)
+		if err != nil {
+			log.Panic(err)
+		}
+		e.lineTemplate = strings.TrimSpace(string(data))
 	}
 
 	var err error
@@ -67,11 +178,23 @@ func environment() *env {
 		}
 	}
 
+	// diagram is rebuilt from the tree, so the tree must contain all children
+	fullTree := e.discover || e.syncDays < 0 || e.linkSource == linkSourceSubIssues
+	if e.showDiagram && !fullTree {
+		log.Printf("Diagram requires the whole hierarchy, ignoring show diagram. sync_days=%v", e.syncDays)
+		e.showDiagram = false
+	}
+
 	e.maxLevels, err = strconv.Atoi(os.Getenv("INPUT_MAX_LEVELS"))
 	if err != nil {
 		e.maxLevels = defaultMaxLevels
 	}
 
+	e.collapseAfter, err = strconv.Atoi(os.Getenv("INPUT_COLLAPSE_AFTER"))
+	if err != nil {
+		e.collapseAfter = 0
+	}
+
 	return e
 }
 
@@ -83,6 +206,37 @@ func (e *env) debugPrint() {
 	log.Printf("Dry run: %v", e.dryRun)
 	log.Printf("Add comments: %v", e.addChangelog)
 	log.Printf("Update closed: %v", e.updateClosed)
+	log.Printf("Link source: %v", e.linkSource)
+	log.Printf("Sync sub-issues: %v", e.syncSubIssues)
+	log.Printf("Comment cycles: %v", e.commentCycles)
+	log.Printf("Prune children: %v", e.prune)
+	log.Printf("Discover children: %v", e.discover)
+	log.Printf("Include pull requests: %v", e.pullRequests)
+	log.Printf("Only merged done: %v", e.onlyMerged)
+	log.Printf("Not planned style: %v", e.notPlanned)
+	log.Printf("Show progress: %v", e.showProgress)
+	log.Printf("Progress bar: %v", e.progressBar)
+	log.Printf("Line template: %v", e.lineTemplate)
+	log.Printf("Sort by: %v", e.sortBy)
+	log.Printf("Priority labels: %v", e.priorities)
+	log.Printf("Resort: %v", e.resort)
+	log.Printf("Group by: %v prefix=%v", e.groupBy, e.groupPrefix)
+	log.Printf("Render mode: %v", e.render)
+	log.Printf("Show diagram: %v", e.showDiagram)
+	log.Printf("Collapse nested: %v", e.collapse)
+	log.Printf("Collapse after: %v", e.collapseAfter)
+	log.Printf("Section markers: %v", e.markers)
+	log.Printf("Section heading: %v", e.heading)
+	log.Printf("Section position: %v", e.position)
+	log.Printf("Comment mode: %v", e.commentMode)
+	log.Printf("Auto close: %v", e.autoClose)
+	log.Printf("Auto reopen: %v", e.autoReopen)
+	log.Printf("Status labels: %v", e.statusLabels)
+	log.Printf("Blocking labels: %v", e.blockingLabels)
+	log.Printf("Show estimates: %v", e.showEstimates)
+	log.Printf("Estimate labels: %v", e.estimateLabels)
+	log.Printf("Weighted progress: %v", e.weighted)
+	log.Printf("Breadcrumbs: %v", e.breadcrumbs)
 }
 
 func (s *service) fetchGithubIssues() ([]*github.Issue, error) {
@@ -116,24 +270,40 @@ func (s *service) fetchGithubIssues() ([]*github.Issue, error) {
 	return allIssues, nil
 }
 
-func (s *service) fetchIssuesByID(issues []int) ([]*github.Issue, error) {
-	log.Printf("Fetching issues by ID. count=%v", len(issues))
+func withoutPullRequests(issues []*github.Issue) []*github.Issue {
+	result := make([]*github.Issue, 0, len(issues))
+	for _, i := range issues {
+		if !i.IsPullRequest() {
+			result = append(result, i)
+		}
+	}
+
+	log.Printf("Skipped pull requests. count=%v", len(issues)-len(result))
+
+	return result
+}
+
+func (s *service) fetchIssuesByID(refs []IssueRef) ([]*Issue, error) {
+	log.Printf("Fetching issues by ID. count=%v", len(refs))
 	var wg sync.WaitGroup
+	var mu sync.Mutex
 
-	var allIssues []*github.Issue
-	for _, i := range issues {
+	var allIssues []*Issue
+	for _, r := range refs {
 		wg.Add(1)
-		go func(id int) {
+		go func(ref IssueRef) {
 			defer wg.Done()
 
-			issue, _, err := s.client.Issues.Get(s.ctx, s.env.owner, s.env.repo, id)
+			issue, _, err := s.client.Issues.Get(s.ctx, ref.Owner, ref.Repo, ref.Number)
 			if err != nil {
-				log.Printf("Failed to retrieve an issue. issue=%v err=%v", id, err)
+				log.Printf("Failed to retrieve an issue. issue=%v err=%v", ref, err)
 				return
 			}
 
-			allIssues = append(allIssues, issue)
-		}(i)
+			mu.Lock()
+			allIssues = append(allIssues, NewIssue(issue, ref.Owner, ref.Repo))
+			mu.Unlock()
+		}(r)
 	}
 
 	log.Printf("Waiting for issues to be fetched by ID...")
@@ -155,39 +325,146 @@ func createComment(changelog []string) string {
 	return str.String()
 }
 
-func (s *service) updateIssue(i *Issue, body string, changelog []string) {
+func (s *service) updateIssue(i *Issue, req *github.IssueRequest, changelog []string) {
 	defer s.wg.Done()
 
-	log.Printf("About to update an issue. issue=%v", i.ID)
+	ref := i.Ref()
+	log.Printf("About to update an issue. issue=%v", ref)
 	if s.env.dryRun {
 		log.Printf("Dry run mode.")
 		return
 	}
 
+	_, _, err := s.client.Issues.Edit(s.ctx, ref.Owner, ref.Repo, ref.Number, req)
+
+	if err != nil {
+		log.Printf("Error while editing an issue. issue=%v err=%v", ref, err)
+		return
+	}
+
+	log.Printf("Updated an issue. issue=%v", ref)
+
+	s.addChangelog(ref, changelog)
+}
+
+// updateBody updates the child section and the status label of the parent issue
+func (s *service) updateBody(e *Editor, i *Issue, labels *LabelChange) {
+	body, changeLog, err := e.Update(i, true /*add missing*/)
+	if err != nil {
+		log.Printf("Failed to update issue body. issue=%v err=%v", i.Ref(), err)
+		return
+	}
+
+	// breadcrumb of the sub-parent goes into the same edit, it is not a change of children
+	body = e.UpdateBreadcrumb(i, body)
+
+	changed := body != i.Body
+	changeLog = s.changeLabels(i, labels, changed, changeLog)
+
+	if !changed {
+		log.Printf("Skipping identical issue body. issue=%v", i.Ref())
+		return
+	}
+
 	req := &github.IssueRequest{
 		Body: &body,
 	}
-	_, _, err := s.client.Issues.Edit(s.ctx, s.env.owner, s.env.repo, i.ID, req)
 
+	s.wg.Add(1)
+	go s.updateIssue(i, req, changeLog)
+}
+
+// updateBreadcrumb updates the breadcrumb of the child issue that is not updated as a parent
+func (s *service) updateBreadcrumb(e *Editor, i *Issue) {
+	body := e.UpdateBreadcrumb(i, i.Body)
+	if body == i.Body {
+		log.Printf("Skipping identical breadcrumb. issue=%v", i.Ref())
+		return
+	}
+
+	// changelog comments are not posted to children for breadcrumb edits
+	s.wg.Add(1)
+	go s.updateIssue(i, &github.IssueRequest{Body: &body}, nil)
+}
+
+func (s *service) addChangelog(ref IssueRef, changelog []string) {
+	if !s.env.addChangelog || len(changelog) == 0 {
+		return
+	}
+
+	body := createComment(changelog)
+	comment := &github.IssueComment{
+		Body: &body,
+	}
+	_, _, err := s.client.Issues.CreateComment(s.ctx, ref.Owner, ref.Repo, ref.Number, comment)
 	if err != nil {
-		log.Printf("Error while editing an issue. issue=%v err=%v", i.ID, err)
+		log.Printf("Error while adding a comment. issue=%v err=%v", ref, err)
 		return
 	}
 
-	log.Printf("Updated an issue. issue=%v", i.ID)
+	log.Printf("Added a comment to the issue. issue=%v", ref)
+}
+
+// findComment returns the first comment of the issue that satisfies the predicate
+func (s *service) findComment(ref IssueRef, match func(body string) bool) (*github.IssueComment, error) {
+	opt := &github.IssueListCommentsOptions{
+		ListOptions: github.ListOptions{PerPage: defaultIssuesPerPage},
+	}
+
+	for {
+		comments, resp, err := s.client.Issues.ListComments(s.ctx, ref.Owner, ref.Repo, ref.Number, opt)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, c := range comments {
+			if match(c.GetBody()) {
+				return c, nil
+			}
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+
+		opt.ListOptions.Page = resp.NextPage
+	}
+
+	return nil, nil
+}
+
+func (s *service) commentCycles(cycles []issueCycle) {
+	for _, c := range cycles {
+		ref := c.Broken.child
+		body := c.Comment()
+
+		existing, err := s.findComment(ref, func(b string) bool { return b == body })
+		if err != nil {
+			log.Printf("Error while listing comments. issue=%v err=%v", ref, err)
+			continue
+		}
+
+		if existing != nil {
+			log.Printf("Cycle is already reported. issue=%v", ref)
+			continue
+		}
+
+		log.Printf("About to report a cycle. issue=%v cycle=%v", ref, c)
+		if s.env.dryRun {
+			log.Printf("Dry run mode.")
+			continue
+		}
 
-	if s.env.addChangelog && len(changelog) > 0 {
-		body := createComment(changelog)
 		comment := &github.IssueComment{
 			Body: &body,
 		}
-		_, _, err = s.client.Issues.CreateComment(s.ctx, s.env.owner, s.env.repo, i.ID, comment)
+		_, _, err = s.client.Issues.CreateComment(s.ctx, ref.Owner, ref.Repo, ref.Number, comment)
 		if err != nil {
-			log.Printf("Error while adding a comment. issue=%v err=%v", i.ID, err)
-			return
+			log.Printf("Error while adding a comment. issue=%v err=%v", ref, err)
+			continue
 		}
 
-		log.Printf("Added a comment to the issue. issue=%v", i.ID)
+		log.Printf("Reported a cycle. issue=%v", ref)
 	}
 }
 
@@ -213,48 +490,169 @@ func main() {
 		log.Panic(err)
 	}
 
+	if !env.pullRequests {
+		ghIssues = withoutPullRequests(ghIssues)
+	}
+
 	if len(ghIssues) == 0 {
 		fmt.Println(fmt.Sprintf(`::set-output name=updatedIssues::%s`, "1"))
 		return
 	}
 
-	tr := NewTree(ghIssues)
-	missing, err := svc.fetchIssuesByID(tr.missing)
-	if err != nil {
-		log.Panic(err)
+	tr := NewTree(ghIssues, env.owner, env.repo, env.linkSource != linkSourceSubIssues /*text links*/)
+	if env.linkSource != linkSourceText {
+		svc.addSubIssueLinks(tr)
 	}
-	tr.AddParentIssues(missing)
+
+	// walk up the hierarchy so every ancestor of the changed issue is refreshed
+	for refs := tr.Missing(); len(refs) > 0; refs = tr.Missing() {
+		missing, err := svc.fetchIssuesByID(refs)
+		if err != nil {
+			log.Panic(err)
+		}
+		tr.AddParentIssues(missing)
+	}
+
+	// native sub-issues already contain all children
+	if env.discover && env.linkSource != linkSourceSubIssues {
+		svc.discoverChildren(tr)
+	}
+
 	issues := tr.Issues()
 
+	if env.showEstimates || env.weighted {
+		tr.SetEstimates(parseEstimateMapping(env.estimateLabels))
+	}
+
+	if env.commentCycles {
+		svc.commentCycles(tr.cycles)
+	}
+
+	if env.syncSubIssues {
+		svc.syncSubIssues(issues)
+	}
+
 	e := &Editor{
-		MaxLevels: svc.env.maxLevels,
+		MaxLevels:        svc.env.maxLevels,
+		Prune:            svc.env.prune,
+		OnlyMergedDone:   svc.env.onlyMerged,
+		NotPlanned:       svc.env.notPlanned,
+		ShowProgress:     svc.env.showProgress,
+		ProgressBar:      svc.env.progressBar,
+		SortBy:           svc.env.sortBy,
+		PriorityLabels:   svc.env.priorities,
+		Resort:           svc.env.resort,
+		GroupBy:          svc.env.groupBy,
+		GroupLabelPrefix: svc.env.groupPrefix,
+		Render:           svc.env.render,
+		ShowDiagram:      svc.env.showDiagram,
+		CollapseNested:   svc.env.collapse,
+		CollapseAfter:    svc.env.collapseAfter,
+		Markers:          svc.env.markers,
+		Heading:          svc.env.heading,
+		Position:         svc.env.position,
+		ShowEstimates:    svc.env.showEstimates,
+		WeightedProgress: svc.env.weighted,
+	}
+
+	if len(svc.env.lineTemplate) > 0 {
+		e.LineTemplate, err = NewLineTemplate(svc.env.lineTemplate)
+		if err != nil {
+			log.Panic(err)
+		}
 	}
 
+	// sections list children that were not fetched in this run
+	if !svc.env.commentMode {
+		tr.SetListed(e.ListedChildren)
+	}
+
+	if svc.env.breadcrumbs {
+		e.Ancestors = tr.Ancestors
+	}
+
+	if e.Prune != PruneNone {
+		e.Linked = newLinkChecker(svc, tr).Linked
+		// sections in comments are not checked to save requests
+		if !svc.env.commentMode {
+			issues = append(issues, tr.FormerParents(e.hasSection)...)
+		}
+	}
+
+	policy := &StatePolicy{
+		AutoClose:      svc.env.autoClose,
+		AutoReopen:     svc.env.autoReopen,
+		OnlyMergedDone: svc.env.onlyMerged,
+	}
+
+	labeler := &StatusLabeler{
+		BlockingLabels: svc.env.blockingLabels,
+		OnlyMergedDone: svc.env.onlyMerged,
+	}
+
+	toClose := make([]*Issue, 0)
+	// bodies of these issues already have the breadcrumb
+	updated := make(map[IssueRef]bool)
+
 	for _, i := range issues {
+		// sections in comments are fetched only if children states are needed
+		needListed := svc.env.statusLabels || svc.env.autoClose || svc.env.autoReopen || svc.env.breadcrumbs
+		if svc.env.commentMode && needListed {
+			i.Listed = svc.listedChildren(e, i)
+		}
+
+		action, child := policy.Evaluate(i)
+		if action == ActionReopen {
+			// reopened parent is updated as any other opened issue
+			svc.wg.Add(1)
+			go svc.setIssueState(i, "open", "reopened", reopenComment(child, i.Owner, i.Repo))
+			i.Status = StatusOpened
+		}
+
+		if action == ActionClose {
+			toClose = append(toClose, i)
+		}
+
 		canProcess := i.IsOpened() || (i.IsClosed() && svc.env.updateClosed)
 		if !canProcess {
-			log.Printf("Skipping issue update. issue=%v status=%v", i.ID, i.Status)
+			log.Printf("Skipping issue update. issue=%v status=%v", i.Ref(), i.Status)
 			continue
 		}
 
-		body, changeLog, err := e.Update(i, true /*add missing*/)
-		if err != nil {
-			log.Printf("Failed to update issue body. issue=%v err=%v", i.ID, err)
-			continue
+		var labels *LabelChange
+		if svc.env.statusLabels {
+			labels = labeler.Change(i)
 		}
 
-		if body == i.Body {
-			log.Printf("Skipping identical issue body. issue=%v", i.ID)
-			continue
+		if svc.env.commentMode {
+			svc.updateComment(e, i, labels)
+		} else {
+			svc.updateBody(e, i, labels)
+			updated[i.Ref()] = true
 		}
+	}
 
-		svc.wg.Add(1)
-		go svc.updateIssue(i, body, changeLog)
+	if svc.env.breadcrumbs {
+		for _, i := range tr.ChildIssues(hasBreadcrumb) {
+			canProcess := i.IsOpened() || (i.IsClosed() && svc.env.updateClosed)
+			if updated[i.Ref()] || !canProcess {
+				continue
+			}
+
+			svc.updateBreadcrumb(e, i)
+		}
 	}
 
 	log.Printf("Waiting for issue update to finish...")
 	svc.wg.Wait()
 
+	// parents are closed with the final list of children
+	for _, i := range toClose {
+		svc.wg.Add(1)
+		go svc.setIssueState(i, "closed", "completed", closeComment())
+	}
+	svc.wg.Wait()
+
 	fmt.Println(fmt.Sprintf(`::set-output name=updatedIssues::%s`, "1"))
 
 	// help logger to flush
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-github/v73/github"
+)
+
+// newTestService creates a service that talks to a local GitHub API stand-in
+func newTestService(t *testing.T, mux *http.ServeMux) *service {
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	client := github.NewClient(nil)
+	baseURL, err := url.Parse(server.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.BaseURL = baseURL
+
+	return &service{
+		ctx:    context.Background(),
+		client: client,
+		env: &env{
+			owner: "owner",
+			repo:  "repo",
+		},
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/google/go-github/v73/github"
+)
+
+type ParentAction int
+
+const (
+	ActionNone ParentAction = iota
+	ActionClose
+	ActionReopen
+)
+
+// StatePolicy decides if the parent has to be closed or reopened
+// according to the state of its descendants
+type StatePolicy struct {
+	AutoClose      bool
+	AutoReopen     bool
+	OnlyMergedDone bool
+}
+
+// Evaluate returns the action and the child issue that caused the reopen,
+// children listed in the existing section of the parent are taken into account
+func (p *StatePolicy) Evaluate(i *Issue) (ParentAction, *Issue) {
+	descendants := make([]*Issue, 0)
+	for _, ci := range i.Descendants() {
+		descendants = append(descendants, ci)
+	}
+	sort.Slice(descendants, func(a, b int) bool { return descendants[a].Ref().Less(descendants[b].Ref()) })
+
+	if len(descendants) == 0 {
+		return ActionNone, nil
+	}
+
+	switch i.Status {
+	case StatusOpened:
+		if !p.AutoClose {
+			return ActionNone, nil
+		}
+
+		for _, ci := range descendants {
+			// state of the listed child that was not fetched is not verified
+			if ci.Unfetched {
+				log.Printf("Not closing parent with unfetched child. issue=%v child=%v", i.Ref(), ci.Ref())
+				return ActionNone, nil
+			}
+
+			if !ci.IsDone(p.OnlyMergedDone) && !ci.IsAbandoned() {
+				return ActionNone, nil
+			}
+		}
+
+		return ActionClose, nil
+	case StatusClosed:
+		// without the section it is not known what changed after the parent was closed
+		listed := i.Listed
+		if !p.AutoReopen || listed == nil {
+			return ActionNone, nil
+		}
+
+		// children reopened after they were shown as done
+		for _, ci := range descendants {
+			if done, ok := listed[ci.Ref()]; ok && done && ci.IsOpened() && !ci.Unfetched {
+				return ActionReopen, ci
+			}
+		}
+
+		// opened children linked after the section was updated
+		for _, ci := range i.Children {
+			if _, ok := listed[ci.Ref()]; !ok && ci.IsOpened() {
+				return ActionReopen, ci
+			}
+		}
+	}
+
+	return ActionNone, nil
+}
+
+// listedChildren returns children in the section of the parent that is kept in the comment
+func (s *service) listedChildren(e *Editor, i *Issue) map[IssueRef]bool {
+	comment, err := s.findChildrenComment(i.Ref())
+	if err != nil {
+		log.Printf("Error while listing comments. issue=%v err=%v", i.Ref(), err)
+		return nil
+	}
+
+	return e.ListedChildren(i, childrenCommentSection(comment.GetBody()))
+}
+
+func closeComment() string {
+	return "All child issues are done, closing the parent issue."
+}
+
+func reopenComment(child *Issue, owner, repo string) string {
+	return fmt.Sprintf("Child issue %v is opened, reopening the parent issue.", child.Ref().Relative(owner, repo))
+}
+
+func (s *service) setIssueState(i *Issue, state, reason, comment string) {
+	defer s.wg.Done()
+
+	ref := i.Ref()
+	log.Printf("About to change issue state. issue=%v state=%v", ref, state)
+	if s.env.dryRun {
+		log.Printf("Dry run mode.")
+		return
+	}
+
+	req := &github.IssueRequest{
+		State:       &state,
+		StateReason: &reason,
+	}
+	_, _, err := s.client.Issues.Edit(s.ctx, ref.Owner, ref.Repo, ref.Number, req)
+	if err != nil {
+		log.Printf("Error while changing issue state. issue=%v err=%v", ref, err)
+		return
+	}
+
+	body := comment
+	_, _, err = s.client.Issues.CreateComment(s.ctx, ref.Owner, ref.Repo, ref.Number, &github.IssueComment{Body: &body})
+	if err != nil {
+		log.Printf("Error while adding a comment. issue=%v err=%v", ref, err)
+		return
+	}
+
+	log.Printf("Changed issue state. issue=%v state=%v", ref, state)
+}
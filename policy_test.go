@@ -0,0 +1,209 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/google/go-github/v73/github"
+)
+
+func listedRefs(done ...bool) map[IssueRef]bool {
+	listed := make(map[IssueRef]bool)
+	for n, d := range done {
+		listed[NewIssueRef("", "", n+2)] = d
+	}
+
+	return listed
+}
+
+func TestStatePolicy(t *testing.T) {
+	tests := []struct {
+		name     string
+		parent   *Issue
+		listed   map[IssueRef]bool
+		action   ParentAction
+		childID  int
+		onlyPRs  bool
+		disabled bool
+	}{
+		{"all done", &Issue{ID: 1, Status: StatusOpened, Children: []*Issue{
+			&Issue{ID: 2, Status: StatusClosed},
+			&Issue{ID: 3, Status: StatusNotPlanned, Children: []*Issue{&Issue{ID: 4, Status: StatusMerged}}},
+		}}, nil, ActionClose, 0, false, false},
+		{"nested opened", &Issue{ID: 1, Status: StatusOpened, Children: []*Issue{
+			&Issue{ID: 2, Status: StatusClosed, Children: []*Issue{&Issue{ID: 4, Status: StatusOpened}}},
+		}}, nil, ActionNone, 0, false, false},
+		{"closed pull request", &Issue{ID: 1, Status: StatusOpened, Children: []*Issue{
+			&Issue{ID: 2, Status: StatusClosed, PullRequest: true},
+		}}, nil, ActionNone, 0, true, false},
+		{"no children", &Issue{ID: 1, Status: StatusOpened}, nil, ActionNone, 0, false, false},
+		{"disabled", &Issue{ID: 1, Status: StatusOpened, Children: []*Issue{
+			&Issue{ID: 2, Status: StatusClosed},
+		}}, nil, ActionNone, 0, false, true},
+		{"reopened child", &Issue{ID: 1, Status: StatusClosed, Children: []*Issue{
+			&Issue{ID: 2, Status: StatusOpened},
+			&Issue{ID: 3, Status: StatusOpened},
+		}}, listedRefs(false, true), ActionReopen, 3, false, false},
+		{"newly linked child", &Issue{ID: 1, Status: StatusClosed, Children: []*Issue{
+			&Issue{ID: 2, Status: StatusClosed},
+			&Issue{ID: 3, Status: StatusOpened},
+		}}, listedRefs(true), ActionReopen, 3, false, false},
+		{"leftover opened child", &Issue{ID: 1, Status: StatusClosed, Children: []*Issue{
+			&Issue{ID: 2, Status: StatusOpened},
+			&Issue{ID: 3, Status: StatusClosed},
+		}}, listedRefs(false, true), ActionNone, 0, false, false},
+		{"leftover nested child", &Issue{ID: 1, Status: StatusClosed, Children: []*Issue{
+			&Issue{ID: 2, Status: StatusClosed, Children: []*Issue{&Issue{ID: 4, Status: StatusOpened}}},
+		}}, listedRefs(true), ActionNone, 0, false, false},
+		{"no section", &Issue{ID: 1, Status: StatusClosed, Children: []*Issue{
+			&Issue{ID: 2, Status: StatusOpened},
+		}}, nil, ActionNone, 0, false, false},
+		{"not planned parent", &Issue{ID: 1, Status: StatusNotPlanned, Children: []*Issue{
+			&Issue{ID: 2, Status: StatusOpened},
+		}}, listedRefs(true), ActionNone, 0, false, false},
+	}
+
+	for _, tt := range tests {
+		p := &StatePolicy{AutoClose: !tt.disabled, AutoReopen: !tt.disabled, OnlyMergedDone: tt.onlyPRs}
+		tt.parent.Listed = tt.listed
+		action, child := p.Evaluate(tt.parent)
+		if action != tt.action {
+			t.Errorf("Action does not match. test=%v actual=%v expected=%v", tt.name, action, tt.action)
+		}
+
+		if tt.childID != 0 && (child == nil || child.ID != tt.childID) {
+			t.Errorf("Child does not match. test=%v actual=%v expected=%v", tt.name, child, tt.childID)
+		}
+	}
+}
+
+func TestStatePolicyIgnoresUpdates(t *testing.T) {
+	// the child was updated after the parent was closed, e.g. by the breadcrumb or a comment
+	body := `### Child issues:
+
+- [ ] Leftover #2
+- [x] Done #3
+`
+	parent := &Issue{ID: 1, Body: body, Status: StatusClosed, Children: []*Issue{
+		&Issue{ID: 2, Title: "Leftover", Status: StatusOpened},
+		&Issue{ID: 3, Title: "Done", Status: StatusClosed},
+	}}
+
+	p := &StatePolicy{AutoReopen: true}
+	parent.Listed = (&Editor{}).ListedChildren(parent, parent.Body)
+	if action, child := p.Evaluate(parent); action != ActionNone {
+		t.Errorf("Parent is reopened. child=%v", child)
+	}
+
+	parent.Children[1].Status = StatusOpened
+	if action, child := p.Evaluate(parent); action != ActionReopen || child.ID != 3 {
+		t.Errorf("Parent is not reopened. action=%v child=%v", action, child)
+	}
+}
+
+func TestStatePolicyUnfetchedChild(t *testing.T) {
+	body := `### Child issues:
+
+- [ ] Old child #10
+- [ ] New child #11
+`
+	// only the child updated in this run is fetched
+	parent := &Issue{ID: 1, Body: body, Status: StatusOpened, Children: []*Issue{
+		&Issue{ID: 11, Title: "New child", Status: StatusClosed},
+	}}
+	parent.Listed = (&Editor{}).ListedChildren(parent, parent.Body)
+
+	p := &StatePolicy{AutoClose: true}
+	if action, _ := p.Evaluate(parent); action != ActionNone {
+		t.Errorf("Parent with opened listed child is closed. action=%v", action)
+	}
+
+	// checked line is not verified either
+	parent.Listed[NewIssueRef("", "", 10)] = true
+	if action, _ := p.Evaluate(parent); action != ActionNone {
+		t.Errorf("Parent with unfetched child is closed. action=%v", action)
+	}
+
+	parent.Children = append(parent.Children, &Issue{ID: 10, Title: "Old child", Status: StatusClosed})
+	if action, _ := p.Evaluate(parent); action != ActionClose {
+		t.Errorf("Parent with all children done is not closed. action=%v", action)
+	}
+}
+
+func TestListedChildren(t *testing.T) {
+	body := `Description #7
+
+### Child issues:
+
+See #5 for context.
+
+- [ ] Opened #2
+  - [X] Nested #4
+- [x] ~~Dropped~~ #3
+`
+	i := &Issue{ID: 1}
+	// struck lines of not planned children are not listed
+	expected := map[IssueRef]bool{
+		NewIssueRef("", "", 2): false,
+		NewIssueRef("", "", 4): true,
+	}
+
+	if listed := (&Editor{}).ListedChildren(i, body); !reflect.DeepEqual(listed, expected) {
+		t.Errorf("Listed children do not match. actual=%v expected=%v", listed, expected)
+	}
+
+	table := `### Child issues:
+
+| Status | Issue | Title | Assignees | Labels | Closed |
+|--------|-------|-------|-----------|--------|--------|
+| ⬜ opened | #2 | Opened | | | |
+| ✅ closed | #3 | Done | | | 2024-05-01 |
+`
+	expected = map[IssueRef]bool{
+		NewIssueRef("", "", 2): false,
+		NewIssueRef("", "", 3): true,
+	}
+
+	if listed := (&Editor{Render: RenderTable}).ListedChildren(i, table); !reflect.DeepEqual(listed, expected) {
+		t.Errorf("Listed table children do not match. actual=%v expected=%v", listed, expected)
+	}
+
+	if listed := (&Editor{}).ListedChildren(i, "Description"); listed != nil {
+		t.Errorf("Children are listed without section. actual=%v", listed)
+	}
+}
+
+func TestSetIssueState(t *testing.T) {
+	var req github.IssueRequest
+	comments := 0
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("PATCH /repos/owner/repo/issues/1", func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&req)
+		w.Write([]byte(`{"number":1}`))
+	})
+	mux.HandleFunc("POST /repos/owner/repo/issues/1/comments", func(w http.ResponseWriter, r *http.Request) {
+		comments++
+		w.Write([]byte(`{"id":1}`))
+	})
+
+	svc := newTestService(t, mux)
+	i := &Issue{ID: 1, Owner: "owner", Repo: "repo"}
+
+	svc.wg.Add(1)
+	svc.setIssueState(i, "closed", "completed", closeComment())
+
+	if req.GetState() != "closed" || req.GetStateReason() != "completed" || comments != 1 {
+		t.Errorf("Issue is not closed. state=%v reason=%v comments=%v", req.GetState(), req.GetStateReason(), comments)
+	}
+
+	svc.env.dryRun = true
+	svc.wg.Add(1)
+	svc.setIssueState(i, "open", "reopened", closeComment())
+
+	if req.GetState() != "closed" || comments != 1 {
+		t.Errorf("Issue is changed in dry run mode. state=%v comments=%v", req.GetState(), comments)
+	}
+}
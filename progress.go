@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	progressPrefix   = "Progress:"
+	progressBarWidth = 10
+)
+
+// rolled-up progress and estimate of the child issue line, e.g. " (3/4, 5 of 13 left)"
+const lineRollupPattern = ` \((?:\d+/\d+|\d+/\d+, [\d.]+ of [\d.]+ left|[\d.]+ of [\d.]+ left)\)`
+
+// matches progress line generated by formatProgress
+var progressLineRegexp = regexp.MustCompile(`^` + progressPrefix + ` (?:[█░]+ )?\d+/\d+ \(\d+%\)$`)
+
+// matches rolled-up progress at the end of the child issue line or the title cell
+var lineProgressRegexp = regexp.MustCompile(lineRollupPattern + `( \||$)`)
+
+type progress struct {
+	Done  int
+	Total int
+	// weights are only set for weighted progress
+	DoneWeight  float64
+	TotalWeight float64
+}
+
+func (p progress) Percent() int {
+	if p.TotalWeight > 0 {
+		return int(p.DoneWeight * 100 / p.TotalWeight)
+	}
+
+	if p.Total == 0 {
+		return 0
+	}
+
+	return p.Done * 100 / p.Total
+}
+
+func (p progress) String() string {
+	return fmt.Sprintf("%v/%v", p.Done, p.Total)
+}
+
+func (p progress) Bar() string {
+	filled := p.Percent() * progressBarWidth / 100
+
+	return strings.Repeat("█", filled) + strings.Repeat("░", progressBarWidth-filled)
+}
+
+// progressOf counts all unique descendants of the issue including the ones only listed
+// in the sections, children closed as not planned are not counted at all
+func (e *Editor) progressOf(i *Issue) progress {
+	p := progress{}
+
+	for _, ci := range i.Descendants() {
+		if ci.IsAbandoned() {
+			continue
+		}
+
+		done := ci.IsDone(e.OnlyMergedDone)
+		p.Total++
+		if done {
+			p.Done++
+		}
+
+		// weights of sub-parents are the sums of weights of their children
+		if e.WeightedProgress && len(ci.Children) == 0 {
+			w := weightOf(ci)
+			p.TotalWeight += w
+			if done {
+				p.DoneWeight += w
+			}
+		}
+	}
+
+	return p
+}
+
+// weightOf returns estimate of the issue, issues without estimate weigh as 1
+func weightOf(i *Issue) float64 {
+	if i.Estimated && i.Estimate > 0 {
+		return i.Estimate
+	}
+
+	return 1
+}
+
+func (e *Editor) formatProgress(i *Issue) string {
+	p := e.progressOf(i)
+
+	if e.ProgressBar {
+		return fmt.Sprintf("%s %s %v (%v%%)", progressPrefix, p.Bar(), p, p.Percent())
+	}
+
+	return fmt.Sprintf("%s %v (%v%%)", progressPrefix, p, p.Percent())
+}
+
+// formatLineProgress returns rolled-up progress and estimate for issues with children
+func (e *Editor) formatLineProgress(i *Issue) string {
+	parts := make([]string, 0, 2)
+	if e.ShowProgress && len(i.Children) > 0 {
+		parts = append(parts, e.progressOf(i).String())
+	}
+
+	if est := e.formatLineEstimate(i); len(est) > 0 {
+		parts = append(parts, est)
+	}
+
+	if len(parts) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf(" (%s)", strings.Join(parts, ", "))
+}
+
+func isProgressLine(line string) bool {
+	return progressLineRegexp.MatchString(strings.TrimSpace(line))
+}
+
+func stripLineProgress(line string) string {
+	return lineProgressRegexp.ReplaceAllString(line, "$1")
+}
+
+// insertBlock puts the block before the first child issue of the section
+func insertBlock(section, block string) string {
+	lines := strings.Split(section, eol)
+
+	// first line is the rest of the section heading
+	at := 1
+	for at < len(lines) && isAllWhitespace(lines[at]) {
+		at++
+	}
+
+	if at == len(lines) {
+		return strings.TrimRight(section, eol) + eol + eol + block + eol
+	}
+
+	result := make([]string, 0, len(lines)+2)
+	result = append(result, lines[:at]...)
+	result = append(result, block, "")
+	result = append(result, lines[at:]...)
+
+	return strings.Join(result, eol)
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"log"
+	"strings"
+)
+
+func parsePruneMode(s string) PruneMode {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "remove":
+		return PruneRemove
+	case "strike":
+		return PruneStrike
+	default:
+		return PruneNone
+	}
+}
+
+// linkChecker verifies that children listed in parent issues still reference them
+type linkChecker struct {
+	svc   *service
+	tr    *tree
+	cache map[issueLink]bool
+}
+
+func newLinkChecker(svc *service, tr *tree) *linkChecker {
+	return &linkChecker{
+		svc:   svc,
+		tr:    tr,
+		cache: make(map[issueLink]bool),
+	}
+}
+
+func (c *linkChecker) Linked(parent, child IssueRef) bool {
+	link := issueLink{parent, child}
+	if linked, ok := c.cache[link]; ok {
+		return linked
+	}
+
+	linked := c.check(parent, child)
+	c.cache[link] = linked
+	log.Printf("Checked child issue link. parent=%v child=%v linked=%v", parent, child, linked)
+
+	return linked
+}
+
+func (c *linkChecker) check(parent, child IssueRef) bool {
+	if c.tr.nodes[parent][child] {
+		return true
+	}
+
+	if c.svc.env.linkSource != linkSourceText {
+		p, err := c.svc.fetchNativeParent(child)
+		if err != nil {
+			// do not remove anything if we are not sure
+			log.Printf("Failed to fetch native parent. issue=%v err=%v", child, err)
+			return true
+		}
+
+		if p != nil && p.Ref() == parent {
+			return true
+		}
+
+		if c.svc.env.linkSource == linkSourceSubIssues {
+			return false
+		}
+	}
+
+	i, ok := c.tr.issues[child]
+	if !ok {
+		fetched, err := c.svc.fetchIssuesByID([]IssueRef{child})
+		if err != nil || len(fetched) == 0 {
+			log.Printf("Failed to fetch child issue. issue=%v err=%v", child, err)
+			return true
+		}
+		i = fetched[0]
+	}
+
+	parents, err := parseParentIssues(i)
+	if err != nil {
+		return false
+	}
+
+	for _, p := range parents {
+		if p == parent {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"log"
+	"strings"
+	"text/template"
+)
+
+const (
+	tableIndent    = "&emsp;"
+	tableTitleCell = 3
+)
+
+var tableHeader = []string{
+	"| Status | Issue | Title | Assignees | Labels | Updated |",
+	"|--------|-------|-------|-----------|--------|---------|",
+}
+
+type RenderMode int
+
+const (
+	RenderChecklist RenderMode = iota
+	RenderTable
+)
+
+func parseRenderMode(s string) RenderMode {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "table":
+		return RenderTable
+	default:
+		return RenderChecklist
+	}
+}
+
+// lineRenderer formats child issue lines and parses them back
+type lineRenderer interface {
+	// indent returns nesting of the line in spaces
+	indent(line string) int
+	render(data *lineData, spaces int, progress string) string
+	strike(line string) (string, bool)
+	// header goes before the first child issue line
+	header() []string
+	// refFirst is true when the issue reference goes before the title
+	refFirst() bool
+	// checked is true when the line shows the issue as done
+	checked(line string) bool
+}
+
+func (e *Editor) renderer() lineRenderer {
+	if e.Render == RenderTable {
+		return tableRenderer{}
+	}
+
+	return checklistRenderer{tmpl: e.lineTemplate()}
+}
+
+type checklistRenderer struct {
+	tmpl *template.Template
+}
+
+func (r checklistRenderer) indent(line string) int {
+	return countPrefixSpaces(line)
+}
+
+func (r checklistRenderer) render(data *lineData, spaces int, progress string) string {
+	var str strings.Builder
+	str.WriteString(strings.Repeat(" ", spaces))
+
+	if err := r.tmpl.Execute(&str, data); err != nil {
+		log.Printf("Failed to execute line template. issue=%v err=%v", data.Ref, err)
+
+		str.Reset()
+		str.WriteString(strings.Repeat(" ", spaces))
+		defaultTemplate.Execute(&str, data)
+	}
+
+	// template output has to stay on one line
+	line := strings.ReplaceAll(str.String(), eol, " ")
+
+	return strings.TrimRight(line, " ") + progress
+}
+
+func (r checklistRenderer) strike(line string) (string, bool) {
+	return strikeLine(line)
+}
+
+func (r checklistRenderer) header() []string {
+	return nil
+}
+
+func (r checklistRenderer) checked(line string) bool {
+	m := listItemRegexp.FindStringSubmatch(line)
+
+	return m != nil && strings.Contains(strings.ToLower(m[1]), "[x]")
+}
+
+// refFirst checks if the template puts the issue reference or URL before the title,
+// so the reference can be told apart from other references in the title
+func (r checklistRenderer) refFirst() bool {
+	var str strings.Builder
+
+	data := &lineData{Title: "\x00title", Ref: "\x00ref", URL: "\x00url"}
+	if err := r.tmpl.Execute(&str, data); err != nil {
+		return false
+	}
+
+	line := str.String()
+	title := strings.Index(line, data.Title)
+
+	for _, ref := range []string{data.Ref, data.URL} {
+		if at := strings.Index(line, ref); at != -1 && at < title {
+			return true
+		}
+	}
+
+	return false
+}
+
+// tableRenderer puts every child issue into a row of the markdown table,
+// nesting is kept as indentation of the title
+type tableRenderer struct{}
+
+func isTableRow(line string) bool {
+	return strings.HasPrefix(strings.TrimSpace(line), "|")
+}
+
+// tableCells splits the row by pipes that are not escaped
+func tableCells(line string) []string {
+	cells := make([]string, 0)
+	start := 0
+
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+		case '|':
+			cells = append(cells, line[start:i])
+			start = i + 1
+		}
+	}
+
+	return append(cells, line[start:])
+}
+
+func escapeCell(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "|", `\|`), eol, " ")
+}
+
+func (r tableRenderer) indent(line string) int {
+	if !isTableRow(line) {
+		return countPrefixSpaces(line)
+	}
+
+	cells := tableCells(strings.TrimSpace(line))
+	if len(cells) <= tableTitleCell {
+		return 0
+	}
+
+	title := strings.TrimSpace(cells[tableTitleCell])
+	level := 0
+	for strings.HasPrefix(title, tableIndent) {
+		title = strings.TrimPrefix(title, tableIndent)
+		level++
+	}
+
+	return level * spacesPerLevel
+}
+
+func (r tableRenderer) render(data *lineData, spaces int, progress string) string {
+	assignees := make([]string, 0, len(data.Assignees))
+	for _, a := range data.Assignees {
+		assignees = append(assignees, "@"+a)
+	}
+
+	cells := []string{
+		data.Emoji + " " + data.Status,
+		data.Ref,
+		strings.Repeat(tableIndent, spaces/spacesPerLevel) + data.Marker + escapeCell(data.Title) + progress,
+		strings.Join(assignees, ", "),
+		escapeCell(strings.Join(data.Labels, ", ")),
+		data.UpdatedAt,
+	}
+
+	var str strings.Builder
+	str.WriteString("|")
+	for _, c := range cells {
+		if len(c) > 0 {
+			str.WriteString(" " + c + " ")
+		} else {
+			str.WriteString(" ")
+		}
+		str.WriteString("|")
+	}
+
+	return str.String()
+}
+
+// stripUpdatedCell removes the date of the last update from the table row,
+// the date changes with every edit of the child, including edits made by the action
+func stripUpdatedCell(line string) string {
+	cells := tableCells(line)
+	if !isTableRow(line) || len(cells) != len(tableCells(tableHeader[0])) {
+		return line
+	}
+
+	cells[len(cells)-2] = ""
+	return strings.Join(cells, "|")
+}
+
+func (r tableRenderer) strike(line string) (string, bool) {
+	cells := tableCells(line)
+	if !isTableRow(line) || len(cells) <= tableTitleCell+1 {
+		return line, false
+	}
+
+	title := strings.TrimSpace(cells[tableTitleCell])
+	indent := ""
+	for strings.HasPrefix(title, tableIndent) {
+		title = strings.TrimPrefix(title, tableIndent)
+		indent += tableIndent
+	}
+
+	struck := "~~" + strings.ReplaceAll(title, "~~", "") + "~~"
+	if title == struck {
+		return line, false
+	}
+
+	cells[tableTitleCell] = " " + indent + struck + " "
+
+	return strings.Join(cells, "|"), true
+}
+
+func (r tableRenderer) header() []string {
+	return tableHeader
+}
+
+func (r tableRenderer) refFirst() bool {
+	return true
+}
+
+// checked looks for emojis of done issues in the status cell
+func (r tableRenderer) checked(line string) bool {
+	cells := tableCells(line)
+	if !isTableRow(line) || len(cells) < 2 {
+		return false
+	}
+
+	status := cells[1]
+	return strings.Contains(status, "✅") || strings.Contains(status, "🟣") || strings.Contains(status, "⛔")
+}
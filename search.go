@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/google/go-github/v73/github"
+)
+
+const (
+	searchResultsPerPage = 100
+)
+
+// searchChildren finds issues of the repository with the "Parent: #N" line in their body,
+// results have to be verified because search ignores punctuation
+func (s *service) searchChildren(parent IssueRef) ([]*Issue, error) {
+	var allIssues []*Issue
+
+	ref := parent.Relative(s.env.owner, s.env.repo)
+	phrases := make([]string, 0, len(parentIssueMarks))
+	for _, mark := range parentIssueMarks {
+		phrases = append(phrases, fmt.Sprintf(`"%s: %s"`, mark, ref))
+	}
+
+	query := fmt.Sprintf(`repo:%s/%s in:body %s`, s.env.owner, s.env.repo, strings.Join(phrases, " OR "))
+	if !s.env.pullRequests {
+		query += " is:issue"
+	}
+	opt := &github.SearchOptions{
+		ListOptions: github.ListOptions{PerPage: searchResultsPerPage},
+	}
+
+	for {
+		result, resp, err := s.client.Search.Issues(s.ctx, query, opt)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, gi := range result.Issues {
+			owner, repo := issueRepository(gi, s.env.owner, s.env.repo)
+			allIssues = append(allIssues, NewIssue(gi, owner, repo))
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+
+		opt.ListOptions.Page = resp.NextPage
+	}
+
+	log.Printf("Searched child issues. parent=%v query=%v count=%v", parent, query, len(allIssues))
+
+	return allIssues, nil
+}
+
+// discoverChildren completes child sets of all parents in the tree
+func (s *service) discoverChildren(tr *tree) {
+	// requests are sequential because search API has a lower rate limit
+	for _, p := range tr.parents() {
+		children, err := s.searchChildren(p)
+		if err != nil {
+			log.Printf("Failed to search child issues. issue=%v err=%v", p, err)
+			continue
+		}
+
+		tr.AddChildren(p, children)
+	}
+}
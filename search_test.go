@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v73/github"
+)
+
+func TestDiscoverChildren(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /search/issues", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query().Get("q")
+		if !strings.Contains(q, "repo:owner/repo") || !strings.Contains(q, `"Parent: #1" OR "Epic: #1" OR "Parent issue: #1"`) {
+			t.Errorf("Unexpected search query. q=%v", q)
+		}
+
+		fmt.Fprint(w, `{"total_count":3,"items":[
+			{"number":3,"title":"Old child","state":"open","body":"Parent: #1"},
+			{"number":4,"title":"Mentions parent","state":"open","body":"Related to #1"},
+			{"number":5,"title":"Another parent","state":"open","body":"Epic: #10"}
+		]}`)
+	})
+
+	svc := newTestService(t, mux)
+	tr := NewTree([]*github.Issue{
+		newGithubIssue(2, "Parent: #1"),
+	}, "owner", "repo", true /*text links*/)
+
+	svc.discoverChildren(tr)
+
+	children := sortedRefs(tr.nodes[NewIssueRef("owner", "repo", 1)])
+	if fmt.Sprint(children) != "[owner/repo#2 owner/repo#3]" {
+		t.Errorf("Children do not match. actual=%v", children)
+	}
+
+	if len(tr.nodes) != 1 {
+		t.Errorf("Unrelated parent was added. nodes=%v", tr.nodes)
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"strings"
+)
+
+const (
+	sectionStartMarker = "<!-- child-issues:start -->"
+	sectionEndMarker   = "<!-- child-issues:end -->"
+)
+
+type SectionPosition int
+
+const (
+	SectionBottom SectionPosition = iota
+	SectionTop
+)
+
+func parseSectionPosition(s string) SectionPosition {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "top":
+		return SectionTop
+	default:
+		return SectionBottom
+	}
+}
+
+// section is the location of the child issues section in the issue body
+type section struct {
+	Start int
+	// child issues go after the heading
+	Content    int
+	ContentEnd int
+	End        int
+	Marked     bool
+}
+
+func (e *Editor) heading() string {
+	if len(e.Heading) > 0 {
+		return e.Heading
+	}
+
+	return issueSectionHead
+}
+
+// markers are required on top, otherwise the whole description is the section on the next run
+func (e *Editor) markers() bool {
+	return e.Markers || e.Position == SectionTop
+}
+
+func headingLevel(line string) int {
+	trimmed := strings.TrimSpace(line)
+	level := len(trimmed) - len(strings.TrimLeft(trimmed, "#"))
+	if level == 0 || (len(trimmed) > level && trimmed[level] != ' ') {
+		return 0
+	}
+
+	return level
+}
+
+// findSection finds the section between markers or after the heading,
+// sections without markers end at the next heading of the same or higher level
+func (e *Editor) findSection(body string) (section, bool) {
+	if start := strings.Index(body, sectionStartMarker); start != -1 {
+		if end := strings.Index(body[start:], sectionEndMarker); end != -1 {
+			s := section{
+				Start:      start,
+				Content:    start + len(sectionStartMarker),
+				ContentEnd: start + end,
+				End:        start + end + len(sectionEndMarker),
+				Marked:     true,
+			}
+
+			// heading is rendered again
+			inner := body[s.Content:s.ContentEnd]
+			skipped := len(inner) - len(strings.TrimLeft(inner, "\r\n"))
+			line := strings.TrimRight(strings.SplitN(inner[skipped:], eol, 2)[0], "\r")
+			if headingLevel(line) > 0 {
+				s.Content += skipped + len(line)
+			}
+
+			return s, true
+		}
+	}
+
+	heading := e.heading()
+	start := strings.LastIndex(body, heading)
+	if start == -1 && heading != issueSectionHead {
+		heading = issueSectionHead
+		start = strings.LastIndex(body, heading)
+	}
+
+	if start == -1 {
+		return section{}, false
+	}
+
+	s := section{Start: start, Content: start + len(heading), ContentEnd: len(body), End: len(body)}
+	if !e.markers() {
+		return s, true
+	}
+
+	// text after the next heading is not managed anymore
+	level := headingLevel(heading)
+	offset := s.Content
+	fenced := false
+	for _, line := range strings.SplitAfter(body[s.Content:], eol) {
+		if isCodeFence(line) {
+			fenced = !fenced
+		}
+
+		if l := headingLevel(line); offset > s.Content && !fenced && l > 0 && l <= level {
+			s.ContentEnd, s.End = offset, offset
+			break
+		}
+		offset += len(line)
+	}
+
+	return s, true
+}
+
+func (e *Editor) hasSection(body string) bool {
+	_, ok := e.findSection(body)
+	return ok
+}
+
+// ListedChildren returns references of the issues in the section of the body and whether
+// they are shown as done, nil is returned if the body has no section,
+// struck lines of not planned or stale children are not counted
+func (e *Editor) ListedChildren(i *Issue, body string) map[IssueRef]bool {
+	sec, ok := e.findSection(body)
+	if !ok {
+		return nil
+	}
+
+	ctx := &editContext{Owner: i.Owner, Repo: i.Repo, RefFirst: e.renderer().refFirst(), Issues: i.ToMap()}
+	listed := make(map[IssueRef]bool)
+
+	for _, line := range strings.Split(body[sec.Content:sec.ContentEnd], eol) {
+		line = strings.TrimRight(line, "\r")
+		if !isItemLine(line) {
+			continue
+		}
+
+		id, err := lineRef(line, ctx)
+		if err != nil {
+			continue
+		}
+
+		if managed, _ := splitAnnotation(line, ctx); strings.Contains(managed, "~~") {
+			continue
+		}
+
+		listed[id] = listed[id] || e.renderer().checked(line)
+	}
+
+	return listed
+}
+
+// wrapSection puts the section with the heading between markers
+func wrapSection(heading, content, sectionEOL string) string {
+	return sectionStartMarker + sectionEOL + heading + strings.TrimRight(content, "\r\n") + sectionEOL + sectionEndMarker
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+type SortPolicy int
+
+const (
+	SortByNumber SortPolicy = iota
+	SortByStatus
+	SortByTitle
+	SortByPriority
+	SortByMilestone
+)
+
+func parseSortPolicy(s string) SortPolicy {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "status":
+		return SortByStatus
+	case "title":
+		return SortByTitle
+	case "priority":
+		return SortByPriority
+	case "milestone":
+		return SortByMilestone
+	default:
+		return SortByNumber
+	}
+}
+
+func (e *Editor) priority(i *Issue) int {
+	for p, pl := range e.PriorityLabels {
+		for _, l := range i.Labels {
+			if strings.EqualFold(l, pl) {
+				return p
+			}
+		}
+	}
+
+	return len(e.PriorityLabels)
+}
+
+// less compares issues by the sort policy and then by the number
+func (e *Editor) less(a, b *Issue) bool {
+	switch e.SortBy {
+	case SortByStatus:
+		// opened issues go first
+		ad, bd := a.IsDone(e.OnlyMergedDone), b.IsDone(e.OnlyMergedDone)
+		if ad != bd {
+			return !ad
+		}
+	case SortByTitle:
+		at, bt := strings.ToLower(a.Title), strings.ToLower(b.Title)
+		if at != bt {
+			return at < bt
+		}
+	case SortByPriority:
+		ap, bp := e.priority(a), e.priority(b)
+		if ap != bp {
+			return ap < bp
+		}
+	case SortByMilestone:
+		// issues without milestone go last
+		ad, bd := a.MilestoneDue, b.MilestoneDue
+		if ad.IsZero() != bd.IsZero() {
+			return !ad.IsZero()
+		}
+		if !ad.Equal(bd) {
+			return ad.Before(bd)
+		}
+	}
+
+	return a.Ref().Less(b.Ref())
+}
+
+func (e *Editor) sorted(issues []*Issue) []*Issue {
+	result := make([]*Issue, len(issues))
+	copy(result, issues)
+
+	sort.SliceStable(result, func(i, j int) bool { return e.less(result[i], result[j]) })
+
+	return result
+}
+
+// lineBlock is a child issue line together with its nested lines
+type lineBlock struct {
+	issue *Issue
+	lines []string
+}
+
+// sortLines reorders runs of sibling child issue lines, nested lines are moved
+// together with their parent line and sorted recursively
+func (e *Editor) sortLines(lines []string, issueMap map[IssueRef]*Issue, ctx *editContext) []string {
+	result := make([]string, 0, len(lines))
+	run := make([]*lineBlock, 0)
+
+	flush := func() {
+		sort.SliceStable(run, func(i, j int) bool {
+			a, b := run[i].issue, run[j].issue
+			// unknown issues keep their place at the end of the run
+			if a == nil || b == nil {
+				return a != nil && b == nil
+			}
+			return e.less(a, b)
+		})
+
+		for _, b := range run {
+			result = append(result, b.lines[0])
+			result = append(result, e.sortLines(b.lines[1:], issueMap, ctx)...)
+		}
+		run = run[:0]
+	}
+
+	indent := -1
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		spaces := e.renderer().indent(line)
+		if indent == -1 && !isAllWhitespace(line) {
+			indent = spaces
+		}
+
+		id, err := lineRef(line, ctx)
+		if isAllWhitespace(line) || spaces != indent || err != nil || isGroupHeading(line) {
+			flush()
+			result = append(result, line)
+			i++
+			continue
+		}
+
+		block := &lineBlock{issue: issueMap[id], lines: []string{line}}
+		for i++; i < len(lines) && !isAllWhitespace(lines[i]) && e.renderer().indent(lines[i]) > indent; i++ {
+			block.lines = append(block.lines, lines[i])
+		}
+		run = append(run, block)
+	}
+
+	flush()
+
+	return result
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/google/go-github/v73/github"
+)
+
+const (
+	linkSourceText      = "text"
+	linkSourceSubIssues = "sub-issues"
+	linkSourceAll       = "all"
+	subIssuesPerPage    = 100
+)
+
+func parseLinkSource(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	switch s {
+	case linkSourceSubIssues, linkSourceAll:
+		return s
+	default:
+		return linkSourceText
+	}
+}
+
+// issueRepository returns owner and repo from the repository_url of the issue
+func issueRepository(i *github.Issue, owner, repo string) (string, string) {
+	parts := strings.Split(strings.TrimRight(i.GetRepositoryURL(), "/"), "/")
+	if len(parts) < 3 || parts[len(parts)-3] != "repos" {
+		return owner, repo
+	}
+
+	return parts[len(parts)-2], parts[len(parts)-1]
+}
+
+func (s *service) fetchSubIssues(parent IssueRef) ([]*Issue, error) {
+	var allIssues []*Issue
+
+	opt := &github.IssueListOptions{
+		ListOptions: github.ListOptions{PerPage: subIssuesPerPage},
+	}
+
+	for {
+		subIssues, resp, err := s.client.SubIssue.ListByIssue(s.ctx, parent.Owner, parent.Repo, int64(parent.Number), opt)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, si := range subIssues {
+			gi := (*github.Issue)(si)
+			owner, repo := issueRepository(gi, parent.Owner, parent.Repo)
+			allIssues = append(allIssues, NewIssue(gi, owner, repo))
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+
+		opt.ListOptions.Page = resp.NextPage
+	}
+
+	log.Printf("Fetched sub-issues. parent=%v count=%v", parent, len(allIssues))
+
+	return allIssues, nil
+}
+
+// fetchNativeParent returns nil if the issue does not have a parent
+func (s *service) fetchNativeParent(child IssueRef) (*Issue, error) {
+	u := fmt.Sprintf("repos/%v/%v/issues/%v/parent", child.Owner, child.Repo, child.Number)
+	req, err := s.client.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	parent := new(github.Issue)
+	resp, err := s.client.Do(s.ctx, req, parent)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	owner, repo := issueRepository(parent, child.Owner, child.Repo)
+
+	return NewIssue(parent, owner, repo), nil
+}
+
+// addSubIssueLinks adds native parents of every issue in the tree up to the root
+// and native sub-issues of every parent
+func (s *service) addSubIssueLinks(tr *tree) {
+	// requests are sequential to stay below secondary rate limits
+	queue := make([]*Issue, 0, len(tr.issues))
+	for _, i := range tr.issues {
+		queue = append(queue, i)
+	}
+
+	checked := make(map[IssueRef]*Issue)
+	for len(queue) > 0 {
+		i := queue[0]
+		queue = queue[1:]
+
+		if _, ok := checked[i.Ref()]; ok {
+			continue
+		}
+		checked[i.Ref()] = i
+
+		p, err := s.fetchNativeParent(i.Ref())
+		if err != nil {
+			log.Printf("Failed to fetch native parent. issue=%v err=%v", i.Ref(), err)
+			continue
+		}
+
+		if p == nil {
+			continue
+		}
+
+		tr.AddLink(p, i)
+		queue = append(queue, p)
+	}
+
+	for _, p := range checked {
+		children, err := s.fetchSubIssues(p.Ref())
+		if err != nil {
+			log.Printf("Failed to fetch sub-issues. issue=%v err=%v", p.Ref(), err)
+			continue
+		}
+
+		for _, ci := range children {
+			tr.AddLink(p, ci)
+		}
+	}
+}
+
+// syncSubIssues mirrors links of the issue hierarchy into native sub-issues
+func (s *service) syncSubIssues(issues []*Issue) {
+	for _, p := range issues {
+		native, err := s.fetchSubIssues(p.Ref())
+		if err != nil {
+			log.Printf("Failed to fetch sub-issues. issue=%v err=%v", p.Ref(), err)
+			continue
+		}
+
+		existing := make(map[IssueRef]bool)
+		for _, ni := range native {
+			existing[ni.Ref()] = true
+		}
+
+		for _, ci := range p.Children {
+			if existing[ci.Ref()] {
+				continue
+			}
+
+			log.Printf("About to add a sub-issue. parent=%v child=%v", p.Ref(), ci.Ref())
+			if s.env.dryRun {
+				log.Printf("Dry run mode.")
+				continue
+			}
+
+			if ci.DatabaseID == 0 {
+				log.Printf("Sub-issue ID is unknown. issue=%v", ci.Ref())
+				continue
+			}
+
+			req := github.SubIssueRequest{SubIssueID: ci.DatabaseID}
+			_, _, err := s.client.SubIssue.Add(s.ctx, p.Owner, p.Repo, int64(p.ID), req)
+			if err != nil {
+				// native sub-issue can have only one parent
+				log.Printf("Error while adding a sub-issue. parent=%v child=%v err=%v", p.Ref(), ci.Ref(), err)
+				continue
+			}
+
+			log.Printf("Added a sub-issue. parent=%v child=%v", p.Ref(), ci.Ref())
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"testing"
+
+	"github.com/google/go-github/v73/github"
+)
+
+func issueJSON(id int64, number int) string {
+	return fmt.Sprintf(`{"id":%v,"number":%v,"title":"Issue %v","state":"open","repository_url":"https://api.github.com/repos/owner/repo"}`,
+		id, number, number)
+}
+
+func TestAddSubIssueLinks(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /repos/owner/repo/issues/{number}/parent", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("number") != "3" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, issueJSON(1001, 1))
+	})
+	mux.HandleFunc("GET /repos/owner/repo/issues/{number}/sub_issues", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("number") != "1" {
+			fmt.Fprint(w, `[]`)
+			return
+		}
+		fmt.Fprintf(w, `[%v,%v]`, issueJSON(1003, 3), issueJSON(1004, 4))
+	})
+
+	svc := newTestService(t, mux)
+	tr := NewTree([]*github.Issue{
+		newGithubIssue(3, ""),
+		newGithubIssue(5, "Parent: #1"),
+	}, "owner", "repo", false /*text links*/)
+
+	svc.addSubIssueLinks(tr)
+
+	issues := tr.Issues()
+	if len(issues) != 1 || issues[0].ID != 1 {
+		t.Fatalf("Parent issue is not found. issues=%v", issues)
+	}
+
+	children := make([]int, 0)
+	for _, ci := range issues[0].Children {
+		children = append(children, ci.ID)
+	}
+	sort.Ints(children)
+
+	if fmt.Sprint(children) != "[3 4]" {
+		t.Errorf("Children do not match. actual=%v expected=%v", children, "[3 4]")
+	}
+}
+
+func TestSyncSubIssues(t *testing.T) {
+	added := make([]int64, 0)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /repos/owner/repo/issues/1/sub_issues", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `[%v]`, issueJSON(1003, 3))
+	})
+	mux.HandleFunc("POST /repos/owner/repo/issues/1/sub_issues", func(w http.ResponseWriter, r *http.Request) {
+		var req github.SubIssueRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+		added = append(added, req.SubIssueID)
+		fmt.Fprint(w, issueJSON(req.SubIssueID, 0))
+	})
+
+	svc := newTestService(t, mux)
+	parent := &Issue{ID: 1, Owner: "owner", Repo: "repo", Children: []*Issue{
+		&Issue{ID: 3, Owner: "owner", Repo: "repo", DatabaseID: 1003},
+		&Issue{ID: 4, Owner: "owner", Repo: "repo", DatabaseID: 1004},
+	}}
+
+	svc.syncSubIssues([]*Issue{parent})
+
+	if len(added) != 1 || added[0] != 1004 {
+		t.Errorf("Added sub-issues do not match. actual=%v expected=%v", added, []int64{1004})
+	}
+}
+
+func TestSyncSubIssuesDryRun(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /repos/owner/repo/issues/1/sub_issues", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[]`)
+	})
+	mux.HandleFunc("POST /repos/owner/repo/issues/1/sub_issues", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Sub-issue was added in dry run mode")
+	})
+
+	svc := newTestService(t, mux)
+	svc.env.dryRun = true
+	parent := &Issue{ID: 1, Owner: "owner", Repo: "repo", Children: []*Issue{
+		&Issue{ID: 3, Owner: "owner", Repo: "repo", DatabaseID: 1003},
+	}}
+
+	svc.syncSubIssues([]*Issue{parent})
+}
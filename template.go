@@ -0,0 +1,93 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+const (
+	defaultLineTemplate = "- {{.Checkbox}} {{.Marker}}{{.Title}} {{.Ref}}"
+	closedAtLayout      = "2006-01-02"
+)
+
+var lineTemplateFuncs = template.FuncMap{
+	"join": strings.Join,
+}
+
+var defaultTemplate = template.Must(NewLineTemplate(defaultLineTemplate))
+
+// lineData is available in the child issue line template
+type lineData struct {
+	Checkbox  string
+	Done      bool
+	Status    string
+	Emoji     string
+	Marker    string
+	Title     string
+	Number    int
+	Ref       string
+	URL       string
+	Assignees []string
+	Labels    []string
+	Milestone string
+	ClosedAt  string
+	UpdatedAt string
+}
+
+var errNoTemplateRef = errors.New("line template does not render issue reference")
+
+// NewLineTemplate parses the template and checks that child issue lines can be parsed back
+func NewLineTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("line").Funcs(lineTemplateFuncs).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+
+	sample := NewIssueRef("owner", "repo", 12345)
+	data := &lineData{
+		Checkbox: "[ ]",
+		Title:    "Title",
+		Number:   sample.Number,
+		Ref:      sample.Relative(sample.Owner, sample.Repo),
+		URL:      fmt.Sprintf("https://github.com/%s/%s/issues/%v", sample.Owner, sample.Repo, sample.Number),
+	}
+
+	var str strings.Builder
+	if err := tmpl.Execute(&str, data); err != nil {
+		return nil, err
+	}
+
+	ref, err := findIssueRef(str.String(), sample.Owner, sample.Repo, checklistRenderer{tmpl: tmpl}.refFirst())
+	if err != nil || ref != sample {
+		return nil, errNoTemplateRef
+	}
+
+	return tmpl, nil
+}
+
+func statusEmoji(i *Issue, done bool) string {
+	switch {
+	case i.Status == StatusNotPlanned:
+		return "⛔"
+	case i.Status == StatusLocked:
+		return "🔒"
+	case i.Status == StatusMerged:
+		return "🟣"
+	case i.Status == StatusDraft:
+		return "📝"
+	case done:
+		return "✅"
+	default:
+		return "⬜"
+	}
+}
+
+func (e *Editor) lineTemplate() *template.Template {
+	if e.LineTemplate != nil {
+		return e.LineTemplate
+	}
+
+	return defaultTemplate
+}